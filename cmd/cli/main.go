@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/noellimx/redditminer/src/infrastructure/migrations"
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+	statisticsservice "github.com/noellimx/redditminer/src/service/statistics"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+const usage = `usage:
+  cli tasks export [-format json|yaml]            write the active tasks to stdout
+  cli tasks import [-dry-run] [-prune] <file>     upsert the tasks of a .json/.yaml document
+  cli migrate up                                  apply pending schema migrations
+  cli migrate down [-steps n]                     revert the last n applied migrations, default 1
+  cli migrate status                              list migrations and when they were applied
+  cli statistics dedupe [-policy keep_first|keep_latest] [-dry-run]
+                                                  delete duplicate observations stored before they were unique,
+                                                  keeping those STATISTICS_CONFLICT_POLICY would keep by default
+  cli statistics rollup [-lookback 720h]          recompute the hourly and daily rollups covering the lookback,
+                                                  and those changed since the last rollup
+
+API_SERVER_ADDRESS is the http server the tasks commands talk to.
+DATABASE_URL is the database the migrate and statistics commands run against.`
+
+func main() {
+	godotenv.Load()
+	serverAddress := os.Getenv("API_SERVER_ADDRESS")
+
+	dbUrl := os.Getenv("DATABASE_URL")
+
+	if len(os.Args) < 3 {
+		log.Fatal(usage)
+	}
+
+	var err error
+	switch os.Args[1] + " " + os.Args[2] {
+	case "tasks export":
+		err = exportTasks(serverAddress, os.Args[3:])
+	case "tasks import":
+		err = importTasks(serverAddress, os.Args[3:])
+	case "migrate up", "migrate down", "migrate status":
+		err = migrate(dbUrl, os.Args[2], os.Args[3:])
+	case "statistics dedupe":
+		err = dedupeStatistics(dbUrl, os.Args[3:])
+	case "statistics rollup":
+		err = rollupStatistics(dbUrl, os.Args[3:])
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func exportTasks(serverAddress string, args []string) error {
+	fs := flag.NewFlagSet("tasks export", flag.ExitOnError)
+	format := fs.String("format", "json", "json or yaml")
+	fs.Parse(args)
+
+	if serverAddress == "" {
+		return fmt.Errorf("API_SERVER_ADDRESS environment variable not set")
+	}
+
+	params := url.Values{}
+	params.Add("format", *format)
+	resp, err := http.Get(serverAddress + "/tasks/export?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+func importTasks(serverAddress string, args []string) error {
+	fs := flag.NewFlagSet("tasks import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print the plan without applying it")
+	prune := fs.Bool("prune", false, "delete active tasks missing from the document")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New(usage)
+	}
+	if serverAddress == "" {
+		return fmt.Errorf("API_SERVER_ADDRESS environment variable not set")
+	}
+
+	file := fs.Arg(0)
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/json"
+	if strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml") {
+		contentType = "application/yaml"
+	}
+
+	params := url.Values{}
+	params.Add("dry_run", fmt.Sprint(*dryRun))
+	params.Add("prune", fmt.Sprint(*prune))
+	resp, err := http.Post(serverAddress+"/tasks/import?"+params.Encode(), contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(respBody))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+func migrate(dbUrl string, direction string, args []string) error {
+	fs := flag.NewFlagSet("migrate "+direction, flag.ExitOnError)
+	steps := fs.Int("steps", 1, "migrations to revert, down only")
+	fs.Parse(args)
+
+	if dbUrl == "" {
+		return fmt.Errorf("DATABASE_URL environment variable not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbUrl)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		return err
+	}
+
+	switch direction {
+	case "up":
+		done, err := migrator.Up()
+		fmt.Printf("applied %d migration(s)\n", len(done))
+		return err
+	case "down":
+		done, err := migrator.Down(*steps)
+		fmt.Printf("reverted %d migration(s)\n", len(done))
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("binary schema version: %d\n", migrator.Latest())
+	for _, s := range statuses {
+		state := "pending"
+		if s.AppliedAt != nil {
+			state = "applied " + s.AppliedAt.Format(time.RFC3339)
+		}
+		if !s.Known {
+			state += " (unknown to this binary)"
+		}
+		fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+	}
+	return migrator.Check()
+}
+
+func dedupeStatistics(dbUrl string, args []string) error {
+	fs := flag.NewFlagSet("statistics dedupe", flag.ExitOnError)
+	defaultPolicy := os.Getenv("STATISTICS_CONFLICT_POLICY")
+	if defaultPolicy == "" {
+		defaultPolicy = string(statisticsrepo.ConflictPolicyKeepFirst)
+	}
+	policy := fs.String("policy", defaultPolicy, "keep_first or keep_latest, default STATISTICS_CONFLICT_POLICY")
+	dryRun := fs.Bool("dry-run", false, "count the duplicates without deleting them")
+	fs.Parse(args)
+
+	if dbUrl == "" {
+		return fmt.Errorf("DATABASE_URL environment variable not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbUrl)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	deleted, err := statisticsrepo.NewAAA(pool).Dedupe(statisticsrepo.ConflictPolicy(*policy), *dryRun)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Printf("%d duplicate observation(s) would be deleted\n", deleted)
+		return nil
+	}
+	fmt.Printf("deleted %d duplicate observation(s)\n", deleted)
+	return nil
+}
+
+func rollupStatistics(dbUrl string, args []string) error {
+	fs := flag.NewFlagSet("statistics rollup", flag.ExitOnError)
+	lookback := fs.Duration("lookback", 30*24*time.Hour, "how far back to recompute")
+	fs.Parse(args)
+
+	if dbUrl == "" {
+		return fmt.Errorf("DATABASE_URL environment variable not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbUrl)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	service := statisticsservice.NewWWW(statisticsrepo.NewAAA(pool), statisticsrepo.ConflictPolicyKeepFirst)
+	return service.Rollup(*lookback)
+}
@@ -10,9 +10,11 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // timezones of /subreddits/{name}/best-time on hosts without a zoneinfo database
 
 	"github.com/noellimx/redditminer/src/config"
 	"github.com/noellimx/redditminer/src/httplog"
+	"github.com/noellimx/redditminer/src/infrastructure/migrations"
 
 	"github.com/noellimx/redditminer/src/controller/middlewares"
 
@@ -27,6 +29,26 @@ import (
 	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
 	statisticsservice "github.com/noellimx/redditminer/src/service/statistics"
 
+	analyticsmux "github.com/noellimx/redditminer/src/controller/mux/analytics"
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+	analyticsservice "github.com/noellimx/redditminer/src/service/analytics"
+
+	retentionmux "github.com/noellimx/redditminer/src/controller/mux/retention"
+	retentionrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/retention"
+	retentionservice "github.com/noellimx/redditminer/src/service/retention"
+
+	trendingmux "github.com/noellimx/redditminer/src/controller/mux/trending"
+	trendingrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/trending"
+	trendingservice "github.com/noellimx/redditminer/src/service/trending"
+
+	watchlistmux "github.com/noellimx/redditminer/src/controller/mux/watchlist"
+	watchlistrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/watchlist"
+	watchlistservice "github.com/noellimx/redditminer/src/service/watchlist"
+
+	schedulermux "github.com/noellimx/redditminer/src/controller/mux/scheduler"
+	schedulerrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/scheduler"
+	schedulerservice "github.com/noellimx/redditminer/src/service/scheduler"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/robfig/cron/v3"
 	"github.com/rs/cors"
@@ -55,18 +77,74 @@ func main() {
 	mux.Handle("/ping", defaultMiddlewares.Finalize(ping.PingHandler{}.ServeHTTP))
 
 	taskRepo := taskrepo.New(DbConnPool)
-	taskService := taskservice.New(taskRepo)
+	taskService := taskservice.New(taskRepo, taskservice.SubredditValidatorFunc(reddit_miner.ValidateSubReddit))
 	taskHandlers := taskmux.NewHandlers(taskService)
 
 	mux.Handle("POST /task", defaultMiddlewares.Finalize(taskHandlers.Create))
 	mux.Handle("DELETE /task", defaultMiddlewares.Finalize(taskHandlers.Delete))
+	mux.Handle("PUT /task/scraper_options", defaultMiddlewares.Finalize(taskHandlers.UpdateScraperOptions))
 	mux.Handle("GET /tasks", defaultMiddlewares.Finalize(taskHandlers.List))
+	mux.Handle("GET /tasks/missed", defaultMiddlewares.Finalize(taskHandlers.Missed))
+	mux.Handle("GET /tasks/export", defaultMiddlewares.Finalize(taskHandlers.Export))
+	mux.Handle("POST /tasks/import", defaultMiddlewares.Finalize(taskHandlers.Import))
+
+	watchlistRepo := watchlistrepo.New(DbConnPool)
+	watchlistService := watchlistservice.New(watchlistRepo)
+	watchlistHandler := watchlistmux.NewHandlers(watchlistService)
+
+	mux.Handle("POST /watchlists", defaultMiddlewares.Finalize(watchlistHandler.Create))
+	mux.Handle("GET /watchlists", defaultMiddlewares.Finalize(watchlistHandler.List))
+	mux.Handle("GET /watchlists/{id}", defaultMiddlewares.Finalize(watchlistHandler.Get))
+	mux.Handle("PUT /watchlists/{id}", defaultMiddlewares.Finalize(watchlistHandler.Update))
+	mux.Handle("DELETE /watchlists/{id}", defaultMiddlewares.Finalize(watchlistHandler.Delete))
+	mux.Handle("GET /watchlists/{id}/series", defaultMiddlewares.Finalize(watchlistHandler.Series))
 
 	statisticsRepo := statisticsrepo.NewAAA(DbConnPool)
-	statisticService := statisticsservice.NewWWW(statisticsRepo)
+	statisticService := statisticsservice.NewWWW(statisticsRepo, statisticsrepo.ConflictPolicy(Config.StatisticsConfig.ConflictPolicy), watchlistService)
 	statisticsHandler := statisticsmux.NewHandlers(statisticService)
 
 	mux.Handle("GET /statistics", defaultMiddlewares.Finalize(statisticsHandler.Get))
+	mux.Handle("GET /posts/{data_ks_id}/series", defaultMiddlewares.Finalize(statisticsHandler.Series))
+
+	analyticsRepo := analyticsrepo.New(DbConnPool)
+	analyticsService := analyticsservice.New(analyticsRepo)
+	analyticsHandler := analyticsmux.NewHandlers(analyticsService)
+
+	mux.Handle("GET /subreddits/{name}/authors/leaderboard", defaultMiddlewares.Finalize(analyticsHandler.Leaderboard))
+	mux.Handle("GET /statistics/compare", defaultMiddlewares.Finalize(analyticsHandler.Compare))
+	mux.Handle("GET /statistics/diff", defaultMiddlewares.Finalize(analyticsHandler.Diff))
+	mux.Handle("GET /subreddits/{name}/stability", defaultMiddlewares.Finalize(analyticsHandler.Stability))
+	mux.Handle("GET /subreddits/{name}/best-time", defaultMiddlewares.Finalize(analyticsHandler.BestTime))
+	mux.Handle("GET /subreddits/{name}/terms", defaultMiddlewares.Finalize(analyticsHandler.Terms))
+	mux.Handle("GET /snapshot", defaultMiddlewares.Finalize(analyticsHandler.Snapshot))
+
+	trendingNotifiers := []trendingservice.Notifier{trendingservice.LogNotifier}
+	if Config.TrendingConfig.WebhookUrl != "" {
+		trendingNotifiers = append(trendingNotifiers, trendingservice.WebhookNotifier(Config.TrendingConfig.WebhookUrl))
+	}
+	trendingRepo := trendingrepo.New(DbConnPool)
+	trendingService := trendingservice.New(trendingRepo, Config.TrendingConfig.ZScore, Config.TrendingConfig.Baseline, trendingNotifiers...)
+	trendingHandler := trendingmux.NewHandlers(trendingService)
+
+	mux.Handle("GET /trending", defaultMiddlewares.Finalize(trendingHandler.List))
+
+	schedulerRepo := schedulerrepo.New(DbConnPool)
+	schedulerService := schedulerservice.New(schedulerRepo, taskService, statisticService, trendingService, Config.SchedulerConfig.ReplicaId, Config.ScraperConfig.SnapshotDir, Config.SchedulerConfig.RunTimeout)
+	schedulerHandler := schedulermux.NewHandlers(schedulerService)
+
+	mux.Handle("GET /scheduler/locks", defaultMiddlewares.Finalize(schedulerHandler.Locks))
+
+	retentionRepo := retentionrepo.New(DbConnPool)
+	retentionService := retentionservice.New(retentionRepo, retentionservice.Policy{
+		Raw:     Config.RetentionConfig.Raw,
+		Hourly:  Config.RetentionConfig.Hourly,
+		Daily:   Config.RetentionConfig.Daily,
+		Archive: Config.RetentionConfig.Archive,
+	}, Config.SchedulerConfig.ReplicaId)
+	retentionHandler := retentionmux.NewHandlers(retentionService)
+
+	mux.Handle("GET /admin/retention", defaultMiddlewares.Finalize(retentionHandler.Get))
+	mux.Handle("POST /admin/retention/run", defaultMiddlewares.Finalize(retentionHandler.Run))
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   append(Config.ServerConfig.Cors.AllowedOrigins, "http://localhost:5173", "http://localhost:4173"),
@@ -86,7 +164,15 @@ func main() {
 		}))
 	}()
 
-	cron := NewWorker(taskService, statisticService)
+	log.Println("Replica id " + Config.SchedulerConfig.ReplicaId)
+	go schedulerService.CatchUp(taskrepo.GranularityHour, Config.SchedulerConfig.MissedRunLookback)
+	go func() {
+		if _, err := retentionService.Run(); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	cron := NewWorker(schedulerService, statisticService, retentionService)
 	cron.Start()
 
 	recvSig := <-interruptSignal
@@ -114,6 +200,8 @@ func Init() (err error) {
 	config.MaxConns = 10
 	config.MinConns = 2
 	config.MaxConnIdleTime = 5 * time.Minute
+	// lets replicas be told apart in pg_stat_activity, e.g. by the advisory lock report
+	config.ConnConfig.RuntimeParams["application_name"] = Config.SchedulerConfig.ReplicaId
 	ctx := context.Background()
 
 	// Create the pool
@@ -126,10 +214,21 @@ func Init() (err error) {
 	if err != nil {
 		panic(err)
 	}
-	return nil
+
+	migrator, err := migrations.New(DbConnPool)
+	if err != nil {
+		return err
+	}
+	if Config.DatabaseConfig.MigrateOnStartup {
+		_, err = migrator.Up()
+		if err != nil {
+			return err
+		}
+	}
+	return migrator.Check()
 }
 
-func NewWorker(taskService *taskservice.Service, statisticsService *statisticsservice.Service) *cron.Cron {
+func NewWorker(schedulerService *schedulerservice.Service, statisticsService *statisticsservice.Service, retentionService *retentionservice.Service) *cron.Cron {
 	c := cron.New(cron.WithChain(
 		cron.Recover(cron.DefaultLogger),
 	))
@@ -143,15 +242,32 @@ func NewWorker(taskService *taskservice.Service, statisticsService *statisticsse
 	@hourly                | Run once an hour, beginning of hour        | 0 * * * *
 	*/
 	c.AddFunc("@every 1m", func() {
-		tasks, err := taskService.GetTasksByInterval(taskrepo.GranularityHour)
+		schedulerService.RunDue(taskrepo.GranularityHour, time.Now().UTC().Truncate(time.Minute))
+	})
+	c.AddFunc("@every 5m", func() {
+		_, err := schedulerService.ExpireRuns()
 		if err != nil {
 			log.Println(err)
 		}
-
-		for _, task := range tasks {
-			go statisticsService.Scrape(task.SubRedditName, reddit_miner.CreatedWithinPast(task.PostsCreatedWithinPast), reddit_miner.OrderByAlgo(task.OrderBy))
+	})
+	c.AddFunc("@every 5m", func() {
+		err := statisticsService.Rollup(0)
+		if err != nil {
+			log.Println(err)
+		}
+	})
+	c.AddFunc("@hourly", func() {
+		// only the window that just elapsed, earlier ones were warned about already
+		_, err := schedulerService.WarnMissed(taskrepo.GranularityHour, taskservice.GranularityToDuration[taskrepo.GranularityHour])
+		if err != nil {
+			log.Println(err)
+		}
+	})
+	c.AddFunc("@hourly", func() {
+		_, err := retentionService.Run()
+		if err != nil {
+			log.Println(err)
 		}
-		log.Printf("Tasks: %#v\n", tasks)
 	})
 	return c
 }
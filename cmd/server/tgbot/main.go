@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"log"
 	"mime"
@@ -14,6 +15,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
 
@@ -53,6 +55,14 @@ func main() {
 			Command:     "now",
 			Description: "view current trending posts in subreddit",
 		},
+		{
+			Command:     "diff",
+			Description: "what changed in a subreddit ranking since earlier",
+		},
+		{
+			Command:     "snapshot",
+			Description: "view a subreddit ranking as it was earlier",
+		},
 	}
 
 	cfg := tgbotapi.NewSetMyCommands(commands...)
@@ -115,6 +125,8 @@ Github: <a href="https://github.com/noellimx/mk-fe.git"> backend </a> |  <a href
 
 /report 	download historical dataset over time
 /now		view current trending posts in subreddit
+/diff		what changed in a subreddit ranking since earlier
+/snapshot	view a subreddit ranking as it was earlier
 				`, userName, website))
 			msg.ParseMode = "HTML"
 			bot.Send(msg)
@@ -160,6 +172,78 @@ Github: <a href="https://github.com/noellimx/mk-fe.git"> backend </a> |  <a href
 			msg.ReplyMarkup = keyboard
 			bot.Send(msg)
 
+		case "diff":
+			client := TaskClient{
+				Host: serverAddress,
+			}
+			resp, err := client.GetList()
+			if err != nil {
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Something went wrong.... %v %v", resp.Error, err))
+				bot.Send(msg)
+				break
+			}
+
+			if len(resp.Data.Tasks) == 0 {
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("No tasks found. Please visit %s to create a task.", website))
+				bot.Send(msg)
+				break
+			}
+
+			var buttons []tgbotapi.InlineKeyboardButton
+			tasks := resp.Data.Tasks
+			slices.SortFunc(tasks, func(a, b Task) int {
+				return strings.Compare(a.SubRedditName, b.SubRedditName)
+			})
+			tasks = slices.CompactFunc(tasks, func(a, b Task) bool {
+				return a.SubRedditName == b.SubRedditName
+			})
+			for _, task := range tasks {
+				buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(task.SubRedditName, "diff"+"_"+task.SubRedditName))
+			}
+			keyboard := tgbotapi.NewInlineKeyboardMarkup(
+				tgbotapi.NewInlineKeyboardRow(buttons...),
+			)
+
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Which subreddit would you like to compare?")
+			msg.ReplyMarkup = keyboard
+			bot.Send(msg)
+
+		case "snapshot":
+			client := TaskClient{
+				Host: serverAddress,
+			}
+			resp, err := client.GetList()
+			if err != nil {
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Something went wrong.... %v %v", resp.Error, err))
+				bot.Send(msg)
+				break
+			}
+
+			if len(resp.Data.Tasks) == 0 {
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("No tasks found. Please visit %s to create a task.", website))
+				bot.Send(msg)
+				break
+			}
+
+			var buttons []tgbotapi.InlineKeyboardButton
+			tasks := resp.Data.Tasks
+			slices.SortFunc(tasks, func(a, b Task) int {
+				return strings.Compare(a.SubRedditName, b.SubRedditName)
+			})
+			tasks = slices.CompactFunc(tasks, func(a, b Task) bool {
+				return a.SubRedditName == b.SubRedditName
+			})
+			for _, task := range tasks {
+				buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(task.SubRedditName, "snapshot"+"_"+task.SubRedditName))
+			}
+			keyboard := tgbotapi.NewInlineKeyboardMarkup(
+				tgbotapi.NewInlineKeyboardRow(buttons...),
+			)
+
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Which subreddit would you like to look back at?")
+			msg.ReplyMarkup = keyboard
+			bot.Send(msg)
+
 		default:
 			switch {
 			case strings.HasPrefix(command, "now"):
@@ -366,6 +450,158 @@ Working on report...`, sName, sName, order, past))
 					bot.Send(msg)
 				}()
 			}
+		case "diff":
+			switch len(commandargs) {
+			case 2:
+				var buttons []tgbotapi.InlineKeyboardButton
+				for _, order := range []string{"top"} {
+					buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(order, callback.Data+"_"+order))
+				}
+				msg := tgbotapi.NewMessage(chatId, "Select Sort By:")
+
+				keyboard := tgbotapi.NewInlineKeyboardMarkup(
+					tgbotapi.NewInlineKeyboardRow(buttons...),
+				)
+				msg.ReplyMarkup = keyboard
+				bot.Send(msg)
+			case 3:
+				var buttons []tgbotapi.InlineKeyboardButton
+				for _, past := range []string{"day", "week", "month"} {
+					buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(past, callback.Data+"_"+past))
+				}
+				msg := tgbotapi.NewMessage(chatId, "Select Post Created Time:")
+				keyboard := tgbotapi.NewInlineKeyboardMarkup(
+					tgbotapi.NewInlineKeyboardRow(buttons...),
+				)
+				msg.ReplyMarkup = keyboard
+				bot.Send(msg)
+			case 4:
+				var buttons []tgbotapi.InlineKeyboardButton
+				for _, since := range []string{"1 Hour Ago", "6 Hours Ago", "24 Hours Ago"} {
+					buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(since, callback.Data+"_"+since))
+				}
+				msg := tgbotapi.NewMessage(chatId, "Compare With:")
+				keyboard := tgbotapi.NewInlineKeyboardMarkup(
+					tgbotapi.NewInlineKeyboardRow(buttons...),
+				)
+				msg.ReplyMarkup = keyboard
+				bot.Send(msg)
+			case 5:
+				sName := commandargs[1]
+				order := commandargs[2]
+				past := commandargs[3]
+				since := commandargs[4]
+
+				at := time.Now()
+				var vs time.Time
+				switch since {
+				case "1 Hour Ago":
+					vs = at.Add(-1 * time.Hour)
+				case "6 Hours Ago":
+					vs = at.Add(-6 * time.Hour)
+				case "24 Hours Ago":
+					vs = at.Add(-24 * time.Hour)
+				default:
+					return
+				}
+
+				go func() {
+					s := StatsClient{
+						Host: serverAddress,
+					}
+
+					resp, err := s.GetDiff(sName, order, past, at, vs, 20)
+					if err != nil {
+						msg := tgbotapi.NewMessage(chatId, fmt.Sprintf("Something went wrong.... %v", err))
+						bot.Send(msg)
+						return
+					}
+
+					msg := tgbotapi.NewMessage(chatId, formatDiff(sName, order, past, resp.Data))
+					msg.ParseMode = "HTML"
+					msg.DisableWebPagePreview = true
+					if _, err := bot.Send(msg); err != nil {
+						log.Printf("diff send error=%v", err)
+						msg := tgbotapi.NewMessage(chatId, fmt.Sprintf("Something went wrong.... %v", err))
+						bot.Send(msg)
+					}
+				}()
+			}
+		case "snapshot":
+			switch len(commandargs) {
+			case 2:
+				var buttons []tgbotapi.InlineKeyboardButton
+				for _, order := range []string{"top"} {
+					buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(order, callback.Data+"_"+order))
+				}
+				msg := tgbotapi.NewMessage(chatId, "Select Sort By:")
+
+				keyboard := tgbotapi.NewInlineKeyboardMarkup(
+					tgbotapi.NewInlineKeyboardRow(buttons...),
+				)
+				msg.ReplyMarkup = keyboard
+				bot.Send(msg)
+			case 3:
+				var buttons []tgbotapi.InlineKeyboardButton
+				for _, past := range []string{"day", "week", "month"} {
+					buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(past, callback.Data+"_"+past))
+				}
+				msg := tgbotapi.NewMessage(chatId, "Select Post Created Time:")
+				keyboard := tgbotapi.NewInlineKeyboardMarkup(
+					tgbotapi.NewInlineKeyboardRow(buttons...),
+				)
+				msg.ReplyMarkup = keyboard
+				bot.Send(msg)
+			case 4:
+				var buttons []tgbotapi.InlineKeyboardButton
+				for _, ago := range []string{"1 Hour Ago", "6 Hours Ago", "24 Hours Ago"} {
+					buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(ago, callback.Data+"_"+ago))
+				}
+				msg := tgbotapi.NewMessage(chatId, "Select Time:")
+				keyboard := tgbotapi.NewInlineKeyboardMarkup(
+					tgbotapi.NewInlineKeyboardRow(buttons...),
+				)
+				msg.ReplyMarkup = keyboard
+				bot.Send(msg)
+			case 5:
+				sName := commandargs[1]
+				order := commandargs[2]
+				past := commandargs[3]
+				ago := commandargs[4]
+
+				at := time.Now()
+				switch ago {
+				case "1 Hour Ago":
+					at = at.Add(-1 * time.Hour)
+				case "6 Hours Ago":
+					at = at.Add(-6 * time.Hour)
+				case "24 Hours Ago":
+					at = at.Add(-24 * time.Hour)
+				default:
+					return
+				}
+
+				go func() {
+					s := StatsClient{
+						Host: serverAddress,
+					}
+
+					text, err := s.GetSnapshotText(sName, order, past, at)
+					if err != nil {
+						msg := tgbotapi.NewMessage(chatId, fmt.Sprintf("Something went wrong.... %v", err))
+						bot.Send(msg)
+						return
+					}
+
+					// telegram messages are limited to 4096 characters
+					if r := []rune(text); len(r) > 4096 {
+						text = string(r[:4096])
+					}
+					msg := tgbotapi.NewMessage(chatId, text)
+					msg.DisableWebPagePreview = true
+					bot.Send(msg)
+				}()
+			}
 		}
 	}
 }
@@ -544,6 +780,187 @@ func (s *StatsClient) GetCSV(subRedditName string, rankOrderAlgoType string, ran
 
 }
 
+type DiffChange struct {
+	DataKsId      string `json:"data_ks_id"`
+	Title         string `json:"title"`
+	PermaLinkPath string `json:"perma_link_path"`
+	RankAt        *int32 `json:"rank_at"`
+	RankVs        *int32 `json:"rank_vs"`
+	RankDelta     *int32 `json:"rank_delta"`
+	ScoreDelta    *int32 `json:"score_delta"`
+}
+
+type GetDiffResponseBodyData struct {
+	AtPoll    time.Time    `json:"at_poll"`
+	VsPoll    time.Time    `json:"vs_poll"`
+	Entered   []DiffChange `json:"entered"`
+	Dropped   []DiffChange `json:"dropped"`
+	Movers    []DiffChange `json:"movers"`
+	Unchanged int          `json:"unchanged"`
+}
+
+type GetDiffResponseBody = Response[GetDiffResponseBodyData]
+
+func (s *StatsClient) GetDiff(subRedditName string, rankOrderAlgoType string, rankPast string, at, vs time.Time, topN int) (GetDiffResponseBody, error) {
+	baseUrl := s.Host + "/statistics/diff"
+
+	params := url.Values{}
+	params.Add("subreddit", subRedditName)
+	params.Add("rank_order_type", rankOrderAlgoType)
+	params.Add("rank_order_created_within_past", rankPast)
+	params.Add("at", at.UTC().Format("2006-01-02T15:04:05.000Z"))
+	params.Add("vs", vs.UTC().Format("2006-01-02T15:04:05.000Z"))
+	params.Add("top_n", strconv.Itoa(topN))
+
+	req, err := http.NewRequest("GET", baseUrl+"?"+params.Encode(), nil)
+	if err != nil {
+		return GetDiffResponseBody{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return GetDiffResponseBody{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return GetDiffResponseBody{}, err
+	}
+
+	var b GetDiffResponseBody
+	if err := json.Unmarshal(body, &b); err != nil {
+		return GetDiffResponseBody{}, err
+	}
+	if resp.StatusCode != 200 {
+		if b.Error != nil {
+			return b, errors.New(*b.Error)
+		}
+		return b, errors.New(resp.Status)
+	}
+	return b, nil
+}
+
+func (s *StatsClient) GetSnapshotText(subRedditName string, rankOrderAlgoType string, rankPast string, at time.Time) (string, error) {
+	baseUrl := s.Host + "/snapshot"
+
+	params := url.Values{}
+	params.Add("subreddit", subRedditName)
+	params.Add("order", rankOrderAlgoType)
+	params.Add("past", rankPast)
+	params.Add("at", at.UTC().Format("2006-01-02T15:04:05.000Z"))
+
+	req, err := http.NewRequest("GET", baseUrl+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 200 {
+		var b Response[any]
+		if json.Unmarshal(body, &b) == nil && b.Error != nil {
+			return "", errors.New(*b.Error)
+		}
+		return "", errors.New(resp.Status)
+	}
+	return string(body), nil
+}
+
+// maxMessageRunes is the length limit of a telegram message.
+const maxMessageRunes = 4096
+
+// formatDiff renders the diff as an HTML message, each list limited to the largest 10.
+// Whole lines are left out past the message limit so that no tag is cut.
+func formatDiff(sName, order, past string, d GetDiffResponseBodyData) string {
+	header := fmt.Sprintf(`SubReddit: <a href="https://reddit.com/%s">r/%s</a> Order: %s T: %s
+%s → %s UTC`, sName, sName, order, past, d.VsPoll.UTC().Format("Jan 02 15:04"), d.AtPoll.UTC().Format("Jan 02 15:04"))
+
+	link := func(c DiffChange) string {
+		return fmt.Sprintf(`<a href="https://reddit.com%s">%s</a>`, c.PermaLinkPath, html.EscapeString(c.Title))
+	}
+	more := func(n int) string {
+		return fmt.Sprintf("\n…and %d more", n)
+	}
+
+	var lines []string
+	if len(d.Entered) > 0 {
+		lines = append(lines, "\n\n🆕 Entered")
+		for i, c := range d.Entered {
+			if i == 10 {
+				lines = append(lines, more(len(d.Entered)-i))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("\n#%d %s", *c.RankAt, link(c)))
+		}
+	}
+	if len(d.Dropped) > 0 {
+		lines = append(lines, "\n\n👋 Dropped")
+		for i, c := range d.Dropped {
+			if i == 10 {
+				lines = append(lines, more(len(d.Dropped)-i))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("\nwas #%d %s", *c.RankVs, link(c)))
+		}
+	}
+	if len(d.Movers) > 0 {
+		lines = append(lines, "\n\n↕️ Movers")
+		movers := d.Movers
+		if len(movers) > 10 {
+			movers = movers[:10]
+		}
+		for _, c := range movers {
+			arrow := "▲"
+			if *c.RankDelta < 0 {
+				arrow = "▼"
+			}
+			score := ""
+			if c.ScoreDelta != nil {
+				score = fmt.Sprintf(" (%+d pts)", *c.ScoreDelta)
+			}
+			lines = append(lines, fmt.Sprintf("\n#%d %s%d%s %s", *c.RankAt, arrow, abs(*c.RankDelta), score, link(c)))
+		}
+	}
+
+	footer := fmt.Sprintf("\n\n%d unchanged.", d.Unchanged)
+	if len(d.Entered) == 0 && len(d.Dropped) == 0 && len(d.Movers) == 0 {
+		footer = "\n\nNo changes."
+	}
+	const cut = "\n…"
+
+	var ss strings.Builder
+	ss.WriteString(header)
+	n := utf8.RuneCountInString(header) + utf8.RuneCountInString(footer) + utf8.RuneCountInString(cut)
+	for _, line := range lines {
+		n += utf8.RuneCountInString(line)
+		if n > maxMessageRunes {
+			ss.WriteString(cut)
+			break
+		}
+		ss.WriteString(line)
+	}
+	ss.WriteString(footer)
+	return ss.String()
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 func extractFilename(contentDisposition string) string {
 	_, params, err := mime.ParseMediaType(contentDisposition)
 	if err != nil {
@@ -15,6 +15,64 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/retention": {
+            "get": {
+                "description": "Lists the retention policy, the monthly partitions of post_observations and the latest partition and retention actions of every replica.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Report observation partitions and retention work.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/retention.GetResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/retention.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/retention/run": {
+            "post": {
+                "description": "Creates the upcoming partitions and expires data past the retention policy, as the hourly job does. Returns the actions taken, none when another replica is running it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run retention now.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/retention.RunResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/retention.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/ping": {
             "get": {
                 "description": "Returns a simple response to test connectivity",
@@ -38,9 +96,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/statistics": {
+        "/posts/{data_ks_id}/series": {
             "get": {
-                "description": "Retrieve time series data in denormalized form.",
+                "description": "Returns the post's rank, score and comment count at every poll, in every ranking context it appeared in, ordered by poll.\nSummaries give the first-seen, last-seen and peak rank overall and per ranking context.\nCSV responses carry the overall summary in the X-First-Seen, X-Last-Seen, X-Peak-Rank and X-Peak-Rank-At headers.",
                 "consumes": [
                     "application/json",
                     " text/csv"
@@ -50,43 +108,15 @@ const docTemplate = `{
                     " text/csv"
                 ],
                 "tags": [
-                    "subreddit"
+                    "post"
                 ],
-                "summary": "Retrieve time series data in denormalized form.",
+                "summary": "Retrieve one post's time series across ranking contexts.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "name",
-                        "name": "subreddit_name",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "[top,best,hot,new]",
-                        "name": "rank_order_type",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "[hour,day,month,year]",
-                        "name": "rank_order_created_within_past",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "1=Minute,2=QuarterHour,3=Hour,4=Daily,5=Monthly",
-                        "name": "granularity",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "true=backfill incomplete data",
-                        "name": "backfill",
-                        "in": "query",
+                        "description": "post id, e.g. t3_abc123",
+                        "name": "data_ks_id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -94,7 +124,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/statistics.GetStatisticsResponseBody"
+                            "$ref": "#/definitions/statistics.GetSeriesResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/statistics.ErrorResponse"
                         }
                     },
                     "500": {
@@ -106,9 +142,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/task": {
-            "post": {
-                "description": "Schedule a job to get subreddit with the given parameters.",
+        "/scheduler/locks": {
+            "get": {
+                "description": "Lists the task locks held across the cluster and the runs in progress, with the replica holding each.",
                 "consumes": [
                     "application/json"
                 ],
@@ -116,178 +152,2291 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "task"
-                ],
-                "summary": "Create a new task to mine subreddit periodically",
-                "parameters": [
-                    {
-                        "description": "Create Request Body",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/task.CreateRequestBody"
-                        }
-                    }
+                    "scheduler"
                 ],
+                "summary": "Report scheduled work held by replicas.",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/scheduler.LocksResponseBody"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/task.ErrorResponse"
+                            "$ref": "#/definitions/scheduler.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Schedule a job to get subreddit with the given parameters.",
+            }
+        },
+        "/snapshot": {
+            "get": {
+                "description": "Returns the stored poll nearest to at, with its exact poll time and the task run that polled it.\ntext/plain is a compact rendering for chat: a header line, then one line per post.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    " text/csv",
+                    " text/plain"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    " text/csv",
+                    " text/plain"
                 ],
                 "tags": [
-                    "task"
+                    "analytics"
                 ],
-                "summary": "Removes a task",
+                "summary": "The ranked listing of a subreddit as stored at a point in time.",
                 "parameters": [
                     {
-                        "description": "Delete Request Body",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/task.DeleteRequestBody"
-                        }
+                        "type": "string",
+                        "description": "subreddit name",
+                        "name": "subreddit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "order",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "past",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "at",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/analytics.SnapshotResponseBody"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/task.ErrorResponse"
+                            "$ref": "#/definitions/analytics.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/tasks": {
-            "delete": {
-                "description": "Get tasks.",
+        "/statistics": {
+            "get": {
+                "description": "Retrieve time series data in denormalized form, ordered by bucket, rank and post.\nWithout page_size and cursor every row is returned. Pages hold whole buckets and are walked with the opaque next_cursor, returned in the body for JSON and in the X-Next-Cursor header for CSV.\nThe rows matching the filter across every page are counted in total, or the X-Total-Count header for CSV.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    " text/csv"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    " text/csv"
                 ],
                 "tags": [
-                    "task"
+                    "subreddit"
+                ],
+                "summary": "Retrieve time series data in denormalized form.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name",
+                        "name": "subreddit_name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "rank_order_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "rank_order_created_within_past",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1=Minute,2=QuarterHour,3=Hour,4=Daily. Hour and Daily are read from rollups and carry min/max/avg aggregates",
+                        "name": "granularity",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "fills the buckets between from_time and to_time a post was not observed in: none (default), null, locf, linear or drop. true is null",
+                        "name": "backfill",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "for backfill=drop, the share of buckets a post must be observed in, default 0.5",
+                        "name": "min_coverage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "best rank to include, default 1",
+                        "name": "min_rank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "worst rank to include, default 20",
+                        "name": "max_rank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows per page, at most 10000; unpaged when omitted, default 1000 with a cursor. A page holds whole buckets, at least one",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "true=add score and comment deltas and velocities, rank delta, hours since created and hours in top n",
+                        "name": "derived",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rank counted as in the top for hours in top n, default 10",
+                        "name": "top_n",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get tasks.",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/task.ListResponseBodyData"
+                            "$ref": "#/definitions/statistics.GetStatisticsResponseBody"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/task.ErrorResponse"
+                            "$ref": "#/definitions/statistics.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_noellimx_redditminer_src_controller_mux_statistics.Post": {
-            "type": "object",
-            "properties": {
-                "author_id": {
-                    "type": "string"
-                },
-                "author_name": {
-                    "type": "string"
-                },
-                "comment_count": {
-                    "type": "integer"
-                },
-                "data_ks_id": {
-                    "type": "string"
-                },
-                "is_synthetic": {
-                    "type": "boolean"
-                },
-                "perma_link_path": {
-                    "type": "string"
-                },
-                "polled_time": {
-                    "type": "string"
-                },
-                "polled_time_rounded_min": {
-                    "type": "string"
-                },
-                "rank": {
-                    "type": "integer"
-                },
-                "rank_order_created_within_past": {
-                    "$ref": "#/definitions/statistics.CreatedWithinPast"
-                },
-                "rank_order_type": {
-                    "$ref": "#/definitions/statistics.OrderByAlgo"
+        },
+        "/statistics/compare": {
+            "get": {
+                "description": "For each subreddit and bucket: mean score and median comment count of the top n, turnover rate of the top n against the previous bucket, and average age in hours of the posts peaking in the bucket.\nCSV is wide: one row per bucket and four columns per subreddit, prefixed with its name.",
+                "consumes": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Compare subreddits on aligned time buckets.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "comma separated names, at most 10",
+                        "name": "subreddits",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "rank_order_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "rank_order_created_within_past",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1=Minute,2=QuarterHour,3=Hour,4=Daily",
+                        "name": "granularity",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "from_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "to_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rank counted as in the top, default 10",
+                        "name": "top_n",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.CompareResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/statistics/diff": {
+            "get": {
+                "description": "Compares the nearest stored polls to the at and vs times: posts that entered, posts that dropped out, and posts that moved with their rank and score deltas. rank_delta is positive when the post moved up since vs.\nCSV has one row per change, with change as entered, dropped or moved.",
+                "consumes": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "What changed in a ranking between two times.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subreddit name",
+                        "name": "subreddit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "rank_order_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "rank_order_created_within_past",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "at",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "vs",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only consider ranks up to n in either poll, default 0 for every rank",
+                        "name": "top_n",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.DiffResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subreddits/{name}/authors/leaderboard": {
+            "get": {
+                "description": "Ranks authors by how their posts placed in the ranking context over the time range: distinct posts reaching the top n, hours in the top n, best rank or peak score.",
+                "consumes": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Rank the authors of a subreddit.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subreddit name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "rank_order_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "rank_order_created_within_past",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "from_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "to_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "posts_in_top_n (default), hours_in_top_n, best_rank or peak_score",
+                        "name": "metric",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rank counted as in the top, default 10",
+                        "name": "top_n",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "authors, default 50, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.LeaderboardResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subreddits/{name}/best-time": {
+            "get": {
+                "description": "Buckets the posts created between from_time and to_time by their creation hour of the week in the timezone. For each bucket: posts, the probability of reaching the top n in the ranking context, and the median peak score.\nJSON matrices are indexed by day, Monday first, then by hour. CSV has one row per day and hour.",
+                "consumes": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "When to post in a subreddit.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subreddit name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "rank_order_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "rank_order_created_within_past",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "post created from, 2006-01-02T15:04:05.000Z",
+                        "name": "from_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "post created before, 2006-01-02T15:04:05.000Z",
+                        "name": "to_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rank counted as in the top, default 10",
+                        "name": "top_n",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA name, default UTC",
+                        "name": "timezone",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.BestTimeResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subreddits/{name}/stability": {
+            "get": {
+                "description": "For each poll against the previous one: posts that entered and left the top n, average tenure in the top n, and the Spearman and Kendall rank correlations over the posts ranked in both polls. Averages over the range are included.\nCSV responses have one row per poll.",
+                "consumes": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Measure how sticky the top n of a subreddit is.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subreddit name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "rank_order_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "rank_order_created_within_past",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "from_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "to_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rank counted as in the top, default 10",
+                        "name": "top_n",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.StabilityResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subreddits/{name}/terms": {
+            "get": {
+                "description": "Tokenizes the titles of the posts in the top n per bucket (lower case, stopwords removed) into n-grams and weighs each by count, reciprocal rank or score.\nTrending terms gained share in the latest bucket with posts against their average share in the earlier buckets.\nCSV has one row per bucket and term of the heaviest terms.",
+                "consumes": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Title terms of a subreddit over time.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subreddit name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "rank_order_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "rank_order_created_within_past",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1=Minute,2=QuarterHour,3=Hour,4=Daily",
+                        "name": "granularity",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "from_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "to_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ranks whose titles are counted, default 25",
+                        "name": "top_n",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "longest n-gram in words, default 2, at most 3",
+                        "name": "n",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "count (default), rank or score",
+                        "name": "weight",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "terms and trending terms, default 50, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.TermsResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task": {
+            "post": {
+                "description": "Schedule a job to get subreddit with the given parameters.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Create a new task to mine subreddit periodically",
+                "parameters": [
+                    {
+                        "description": "Create Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/task.CreateRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Schedule a job to get subreddit with the given parameters.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Removes a task",
+                "parameters": [
+                    {
+                        "description": "Delete Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/task.DeleteRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/scraper_options": {
+            "put": {
+                "description": "Replace the scraper options of a task. They apply from its next run.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Tune the scraper of a task",
+                "parameters": [
+                    {
+                        "description": "Update Scraper Options Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/task.UpdateScraperOptionsRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "delete": {
+                "description": "Get tasks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Get tasks.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/task.ListResponseBodyData"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/export": {
+            "get": {
+                "description": "Export the active tasks as a declarative document that POST /tasks/import accepts.",
+                "produces": [
+                    "application/json",
+                    " application/yaml"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Export active tasks.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "[json,yaml] defaults to json, or taken from the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/task.Document"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/import": {
+            "post": {
+                "description": "Upsert the tasks of a declarative document, keyed on subreddit, interval, order by and posts created within past. Body is JSON, or YAML with a yaml Content-Type.",
+                "consumes": [
+                    "application/json",
+                    " application/yaml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Import tasks.",
+                "parameters": [
+                    {
+                        "description": "Task document",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/task.Document"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "true=return the plan without applying it",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "true=delete active tasks missing from the document",
+                        "name": "prune",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/task.ImportResponseBodyData"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/missed": {
+            "get": {
+                "description": "Get the fully elapsed windows within the lookback where a task has no succeeded run.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Get missed task windows.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Go duration, defaults to 24h",
+                        "name": "lookback",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/task.MissedResponseBodyData"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/task.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trending": {
+            "get": {
+                "description": "Posts whose score or rank velocity reached the trending z-score against the subreddit's baseline after a scrape, newest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trending"
+                ],
+                "summary": "List trending posts of a subreddit.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name",
+                        "name": "subreddit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "how far back, a Go duration, default 24h",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "at most this many events, default 100, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trending.ListResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/trending.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/trending.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/watchlists": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "List keyword watchlists.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ListResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Keywords are matched against the title of every post scraped from then on, in the listed subreddits or in every subreddit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Create a keyword watchlist.",
+                "parameters": [
+                    {
+                        "description": "Watchlist",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.WriteRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.WatchlistResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/watchlists/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Get a keyword watchlist.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "watchlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.WatchlistResponseBody"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Matches of the keywords kept are kept, matches of the removed keywords are deleted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Replace a keyword watchlist.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "watchlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Watchlist",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.WriteRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.WatchlistResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Delete a keyword watchlist with its matches.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "watchlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/watchlists/{id}/series": {
+            "get": {
+                "description": "Per keyword and bucket: distinct posts whose title matched, how many of them were in the top n, and their best rank.\nWithout rank_order_type and rank_order_created_within_past, matches in every ranking context count.\nCSV has one row per bucket and keyword.",
+                "consumes": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    " text/csv"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Mentions of a watchlist's keywords over time.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "watchlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1=Minute,2=QuarterHour,3=Hour,4=Daily",
+                        "name": "granularity",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "from_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "2006-01-02T15:04:05.000Z",
+                        "name": "to_time",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "[top,best,hot,new]",
+                        "name": "rank_order_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "[hour,day,month,year]",
+                        "name": "rank_order_created_within_past",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rank counted as in the top, default 10",
+                        "name": "top_n",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.SeriesResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/watchlist.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "analytics.Author": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "author_name": {
+                    "type": "string"
+                },
+                "best_rank": {
+                    "type": "integer"
+                },
+                "hours_in_top_n": {
+                    "description": "distinct post hours observed in the top n",
+                    "type": "integer"
+                },
+                "peak_score": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "posts": {
+                    "description": "distinct posts observed",
+                    "type": "integer"
+                },
+                "posts_in_top_n": {
+                    "description": "distinct posts reaching the top n",
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.BestTimeResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/analytics.BestTimeResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.BestTimeResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "hours": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "median_peak_score": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "posts": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "probability": {
+                    "description": "share of the posts reaching the top n",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "top_n": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.CompareResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/analytics.CompareResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.CompareResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "subreddits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.CompareSeries"
+                    }
+                },
+                "top_n": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.CompareSeries": {
+            "type": "object",
+            "properties": {
+                "avg_post_age_at_peak_hours": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "mean_score_top_n": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "median_comments_top_n": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "subreddit_name": {
+                    "type": "string"
+                },
+                "turnover_rate": {
+                    "description": "share of the top n that was not in the top n of the previous bucket",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
+        "analytics.DiffChange": {
+            "type": "object",
+            "properties": {
+                "author_name": {
+                    "type": "string"
+                },
+                "data_ks_id": {
+                    "type": "string"
+                },
+                "perma_link_path": {
+                    "type": "string"
+                },
+                "rank_at": {
+                    "type": "integer"
+                },
+                "rank_delta": {
+                    "type": "integer"
+                },
+                "rank_vs": {
+                    "type": "integer"
+                },
+                "score_at": {
+                    "type": "integer"
+                },
+                "score_delta": {
+                    "type": "integer"
+                },
+                "score_vs": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.DiffResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/analytics.DiffResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.DiffResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "at_poll": {
+                    "type": "string"
+                },
+                "dropped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.DiffChange"
+                    }
+                },
+                "entered": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.DiffChange"
+                    }
+                },
+                "movers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.DiffChange"
+                    }
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "vs_poll": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.ErrorResponse": {
+            "type": "object"
+        },
+        "analytics.LeaderboardResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/analytics.LeaderboardResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.LeaderboardResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.Author"
+                    }
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "top_n": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.PollStability": {
+            "type": "object",
+            "properties": {
+                "avg_tenure_hours": {
+                    "type": "number"
+                },
+                "common_posts": {
+                    "description": "ranked in both polls, the correlations are over these",
+                    "type": "integer"
+                },
+                "entered": {
+                    "description": "into the top n since the previous poll",
+                    "type": "integer"
+                },
+                "kendall": {
+                    "type": "number"
+                },
+                "left": {
+                    "description": "the top n since the previous poll",
+                    "type": "integer"
+                },
+                "polled_time_rounded_min": {
+                    "type": "string"
+                },
+                "spearman": {
+                    "type": "number"
+                }
+            }
+        },
+        "analytics.SnapshotPost": {
+            "type": "object",
+            "properties": {
+                "author_name": {
+                    "type": "string"
+                },
+                "comment_count": {
+                    "type": "integer"
+                },
+                "data_ks_id": {
+                    "type": "string"
+                },
+                "perma_link_path": {
+                    "type": "string"
+                },
+                "post_created_at": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.SnapshotResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/analytics.SnapshotResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.SnapshotResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "polled_time": {
+                    "type": "string"
+                },
+                "polled_time_rounded_min": {
+                    "type": "string"
+                },
+                "posts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.SnapshotPost"
+                    }
+                },
+                "rank_order_created_within_past": {
+                    "type": "string"
+                },
+                "rank_order_type": {
+                    "type": "string"
+                },
+                "run_id": {
+                    "type": "integer"
+                },
+                "subreddit_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.StabilityResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/analytics.StabilityResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.StabilityResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "avg_entered": {
+                    "type": "number"
+                },
+                "avg_kendall": {
+                    "type": "number"
+                },
+                "avg_left": {
+                    "type": "number"
+                },
+                "avg_spearman": {
+                    "type": "number"
+                },
+                "avg_tenure_hours": {
+                    "type": "number"
+                },
+                "polls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.PollStability"
+                    }
+                },
+                "top_n": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.Term": {
+            "type": "object",
+            "properties": {
+                "n": {
+                    "type": "integer"
+                },
+                "posts": {
+                    "type": "integer"
+                },
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "term": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "number"
+                },
+                "weights": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
+        "analytics.TermsResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/analytics.TermsResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.TermsResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "latest": {
+                    "description": "bucket trending terms are detected in",
+                    "type": "string"
+                },
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.Term"
+                    }
+                },
+                "trending": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.TrendingTerm"
+                    }
+                },
+                "weight": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.TrendingTerm": {
+            "type": "object",
+            "properties": {
+                "baseline_share": {
+                    "type": "number"
+                },
+                "lift": {
+                    "description": "null for terms new in the latest bucket",
+                    "type": "number"
+                },
+                "posts": {
+                    "description": "in the latest bucket",
+                    "type": "integer"
+                },
+                "share": {
+                    "type": "number"
+                },
+                "term": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_noellimx_redditminer_src_controller_mux_statistics.Derived": {
+            "type": "object",
+            "properties": {
+                "comment_delta": {
+                    "type": "integer"
+                },
+                "comment_velocity": {
+                    "description": "per hour",
+                    "type": "number"
+                },
+                "hours_in_top_n": {
+                    "type": "number"
+                },
+                "hours_since_created": {
+                    "type": "number"
+                },
+                "rank_delta": {
+                    "description": "positive when the post moved up",
+                    "type": "integer"
+                },
+                "score_delta": {
+                    "type": "integer"
+                },
+                "score_velocity": {
+                    "description": "per hour",
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_noellimx_redditminer_src_controller_mux_statistics.Post": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "author_name": {
+                    "type": "string"
+                },
+                "avg_rank": {
+                    "type": "number"
+                },
+                "avg_score": {
+                    "type": "number"
+                },
+                "backfill_method": {
+                    "description": "null, locf or linear for synthetic points",
+                    "type": "string"
+                },
+                "comment_count": {
+                    "type": "integer"
+                },
+                "data_ks_id": {
+                    "type": "string"
+                },
+                "derived": {
+                    "description": "with derived=true",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_noellimx_redditminer_src_controller_mux_statistics.Derived"
+                        }
+                    ]
+                },
+                "is_synthetic": {
+                    "type": "boolean"
+                },
+                "max_rank": {
+                    "type": "integer"
+                },
+                "max_score": {
+                    "type": "integer"
+                },
+                "min_rank": {
+                    "description": "bucket aggregates, set for rollup granularities",
+                    "type": "integer"
+                },
+                "min_score": {
+                    "type": "integer"
+                },
+                "perma_link_path": {
+                    "type": "string"
+                },
+                "polled_time": {
+                    "type": "string"
+                },
+                "polled_time_rounded_min": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "rank_order_created_within_past": {
+                    "$ref": "#/definitions/statistics.CreatedWithinPast"
+                },
+                "rank_order_type": {
+                    "$ref": "#/definitions/statistics.OrderByAlgo"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "subreddit_id": {
+                    "type": "string"
+                },
+                "subreddit_name": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_noellimx_redditminer_src_controller_mux_trending.Event": {
+            "type": "object",
+            "properties": {
+                "data_ks_id": {
+                    "type": "string"
+                },
+                "detected_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "perma_link_path": {
+                    "type": "string"
+                },
+                "polled_time_rounded_min": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "rank_order_created_within_past": {
+                    "type": "string"
+                },
+                "rank_order_type": {
+                    "type": "string"
+                },
+                "rank_velocity": {
+                    "description": "ranks climbed per hour",
+                    "type": "number"
+                },
+                "rank_z": {
+                    "type": "number"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "score_velocity": {
+                    "description": "per hour",
+                    "type": "number"
+                },
+                "score_z": {
+                    "type": "number"
+                },
+                "subreddit_name": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "ping.Response": {
+            "type": "object"
+        },
+        "retention.Action": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
                 },
-                "score": {
+                "ran_at": {
+                    "type": "string"
+                },
+                "replica_id": {
+                    "type": "string"
+                },
+                "rows": {
                     "type": "integer"
                 },
-                "subreddit_id": {
+                "target": {
                     "type": "string"
+                }
+            }
+        },
+        "retention.ErrorResponse": {
+            "type": "object"
+        },
+        "retention.GetResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/retention.GetResponseBodyData"
                 },
-                "subreddit_name": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "retention.GetResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/retention.Action"
+                    }
+                },
+                "partitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/retention.Partition"
+                    }
+                },
+                "policy": {
+                    "$ref": "#/definitions/retention.Policy"
+                }
+            }
+        },
+        "retention.Partition": {
+            "type": "object",
+            "properties": {
+                "from": {
                     "type": "string"
                 },
-                "title": {
+                "name": {
+                    "type": "string"
+                },
+                "to": {
                     "type": "string"
                 }
             }
         },
-        "ping.Response": {
+        "retention.Policy": {
+            "type": "object",
+            "properties": {
+                "daily": {
+                    "type": "string"
+                },
+                "hourly": {
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "drop or archive",
+                    "type": "string"
+                },
+                "raw": {
+                    "description": "0s keeps the data forever",
+                    "type": "string"
+                }
+            }
+        },
+        "retention.RunResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/retention.RunResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "retention.RunResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/retention.Action"
+                    }
+                }
+            }
+        },
+        "scheduler.ErrorResponse": {
             "type": "object"
         },
+        "scheduler.Lock": {
+            "type": "object",
+            "properties": {
+                "backend_start": {
+                    "type": "string"
+                },
+                "pid": {
+                    "type": "integer"
+                },
+                "replica_id": {
+                    "description": "application_name of the session holding the lock",
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "scheduler.LocksResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/scheduler.LocksResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "scheduler.LocksResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "locks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduler.Lock"
+                    }
+                },
+                "replica_id": {
+                    "description": "replica serving this request",
+                    "type": "string"
+                },
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduler.Run"
+                    }
+                }
+            }
+        },
+        "scheduler.Run": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "replica_id": {
+                    "type": "string"
+                },
+                "scheduled_at": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "statistics.CreatedWithinPast": {
             "type": "string",
             "enum": [
                 "hour",
                 "day",
                 "month",
-                "year"
+                "year",
+                "week"
             ],
             "x-enum-varnames": [
                 "CreatedWithinPastHour",
                 "CreatedWithinPastDay",
                 "CreatedWithinPastMonth",
-                "CreatedWithinPastYear"
+                "CreatedWithinPastYear",
+                "CreatedWithinPastWeek"
             ]
         },
         "statistics.ErrorResponse": {
             "type": "object"
         },
+        "statistics.GetSeriesResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/statistics.GetSeriesResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "statistics.GetSeriesResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "author_name": {
+                    "type": "string"
+                },
+                "contexts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/statistics.SeriesContext"
+                    }
+                },
+                "data_ks_id": {
+                    "type": "string"
+                },
+                "first_seen_at": {
+                    "description": "over every ranking context",
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "peak_rank": {
+                    "type": "integer"
+                },
+                "peak_rank_at": {
+                    "type": "string"
+                },
+                "peak_rank_order_created_within_past": {
+                    "$ref": "#/definitions/statistics.CreatedWithinPast"
+                },
+                "peak_rank_order_type": {
+                    "$ref": "#/definitions/statistics.OrderByAlgo"
+                },
+                "perma_link_path": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/statistics.SeriesPoint"
+                    }
+                },
+                "post_created_at": {
+                    "type": "string"
+                },
+                "subreddit_id": {
+                    "type": "string"
+                },
+                "subreddit_name": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
         "statistics.GetStatisticsResponseBody": {
             "type": "object",
             "properties": {
@@ -302,11 +2451,19 @@ const docTemplate = `{
         "statistics.GetStatisticsResponseBodyData": {
             "type": "object",
             "properties": {
+                "next_cursor": {
+                    "description": "empty on the last page",
+                    "type": "string"
+                },
                 "posts": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/github_com_noellimx_redditminer_src_controller_mux_statistics.Post"
                     }
+                },
+                "total": {
+                    "description": "rows matching the filter across every page",
+                    "type": "integer"
                 }
             }
         },
@@ -325,13 +2482,112 @@ const docTemplate = `{
                 "OrderByAlgoNew"
             ]
         },
+        "statistics.SeriesContext": {
+            "type": "object",
+            "properties": {
+                "first_seen_at": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "observations": {
+                    "type": "integer"
+                },
+                "peak_rank": {
+                    "type": "integer"
+                },
+                "peak_rank_at": {
+                    "description": "first poll at the peak rank",
+                    "type": "string"
+                },
+                "peak_score": {
+                    "type": "integer"
+                },
+                "rank_order_created_within_past": {
+                    "$ref": "#/definitions/statistics.CreatedWithinPast"
+                },
+                "rank_order_type": {
+                    "$ref": "#/definitions/statistics.OrderByAlgo"
+                }
+            }
+        },
+        "statistics.SeriesPoint": {
+            "type": "object",
+            "properties": {
+                "comment_count": {
+                    "type": "integer"
+                },
+                "polled_time": {
+                    "type": "string"
+                },
+                "polled_time_rounded_min": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "rank_order_created_within_past": {
+                    "$ref": "#/definitions/statistics.CreatedWithinPast"
+                },
+                "rank_order_type": {
+                    "$ref": "#/definitions/statistics.OrderByAlgo"
+                },
+                "score": {
+                    "type": "integer"
+                }
+            }
+        },
+        "task.CatchUpPolicy": {
+            "type": "string",
+            "enum": [
+                "skip",
+                "once",
+                "all"
+            ],
+            "x-enum-varnames": [
+                "CatchUpPolicySkip",
+                "CatchUpPolicyOnce",
+                "CatchUpPolicyAll"
+            ]
+        },
+        "task.Change": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "[\"create\", \"update\", \"delete\", \"unchanged\"]",
+                    "type": "string"
+                },
+                "after": {
+                    "$ref": "#/definitions/task.Task"
+                },
+                "before": {
+                    "$ref": "#/definitions/task.Task"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
         "task.CreateRequestBody": {
             "type": "object",
             "properties": {
+                "catch_up_policy": {
+                    "description": "[\"skip\", \"once\", \"all\"] applied on startup to missed windows, defaults to skip",
+                    "type": "string"
+                },
+                "ends_at": {
+                    "description": "optional, completed once reached",
+                    "type": "string"
+                },
                 "interval": {
                     "description": "to be executed every interval [\"hour\"]",
                     "type": "string"
                 },
+                "max_runs": {
+                    "description": "optional, completed after this many windows of the interval with a succeeded run",
+                    "type": "integer"
+                },
                 "min_item_count": {
                     "description": "Minimum Item Count to retrieve",
                     "type": "integer"
@@ -344,9 +2600,25 @@ const docTemplate = `{
                     "description": "[\"day\",\"hour\",\"month\",\"year\"]",
                     "type": "string"
                 },
+                "scraper_options": {
+                    "description": "optional",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/task.ScraperOptions"
+                        }
+                    ]
+                },
+                "starts_at": {
+                    "description": "optional, not scheduled before",
+                    "type": "string"
+                },
                 "subreddit_name": {
                     "description": "Subreddit Name",
                     "type": "string"
+                },
+                "validate_subreddit": {
+                    "description": "check the subreddit exists and is not private or banned before creating",
+                    "type": "boolean"
                 }
             }
         },
@@ -365,6 +2637,41 @@ const docTemplate = `{
                 "CreatedWithinPastYear"
             ]
         },
+        "task.Definition": {
+            "type": "object",
+            "properties": {
+                "catch_up_policy": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string"
+                },
+                "max_runs": {
+                    "type": "integer"
+                },
+                "min_item_count": {
+                    "type": "integer"
+                },
+                "order_by": {
+                    "type": "string"
+                },
+                "posts_created_within_past": {
+                    "type": "string"
+                },
+                "scraper_options": {
+                    "$ref": "#/definitions/task.ScraperOptions"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "subreddit_name": {
+                    "type": "string"
+                }
+            }
+        },
         "task.DeleteRequestBody": {
             "type": "object",
             "properties": {
@@ -373,6 +2680,17 @@ const docTemplate = `{
                 }
             }
         },
+        "task.Document": {
+            "type": "object",
+            "properties": {
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/task.Definition"
+                    }
+                }
+            }
+        },
         "task.ErrorResponse": {
             "type": "object"
         },
@@ -385,6 +2703,20 @@ const docTemplate = `{
                 "GranularityHour"
             ]
         },
+        "task.ImportResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/task.Change"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                }
+            }
+        },
         "task.ListResponseBodyData": {
             "type": "object",
             "properties": {
@@ -396,6 +2728,28 @@ const docTemplate = `{
                 }
             }
         },
+        "task.MissedResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/task.MissedWindow"
+                    }
+                }
+            }
+        },
+        "task.MissedWindow": {
+            "type": "object",
+            "properties": {
+                "task_id": {
+                    "type": "integer"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
         "task.OrderByAlgo": {
             "type": "string",
             "enum": [
@@ -411,15 +2765,67 @@ const docTemplate = `{
                 "OrderByAlgoNew"
             ]
         },
+        "task.ScraperOptions": {
+            "type": "object",
+            "properties": {
+                "capture_snapshot": {
+                    "description": "save a screenshot and the html of each scrape",
+                    "type": "boolean"
+                },
+                "debug_log_enabled": {
+                    "type": "boolean"
+                },
+                "max_items": {
+                    "description": "posts ranked beyond are dropped, 0 keeps all",
+                    "type": "integer"
+                },
+                "max_scrolls": {
+                    "description": "bound for until_count, defaults to 1",
+                    "type": "integer"
+                },
+                "retries": {
+                    "description": "extra attempts when a scrape yields no posts",
+                    "type": "integer"
+                },
+                "scroll_strategy": {
+                    "description": "[\"none\", \"once\", \"until_count\"] defaults to once; until_count scrolls until min_item_count posts are loaded",
+                    "type": "string"
+                },
+                "scroll_wait_seconds": {
+                    "description": "wait after each scroll, defaults to 10, 0 for none",
+                    "type": "integer"
+                }
+            }
+        },
+        "task.Status": {
+            "type": "string",
+            "enum": [
+                "active",
+                "completed"
+            ],
+            "x-enum-varnames": [
+                "StatusActive",
+                "StatusCompleted"
+            ]
+        },
         "task.Task": {
             "type": "object",
             "properties": {
+                "catch_up_policy": {
+                    "$ref": "#/definitions/task.CatchUpPolicy"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "interval": {
                     "$ref": "#/definitions/task.Granularity"
                 },
+                "max_runs": {
+                    "type": "integer"
+                },
                 "min_item_count": {
                     "type": "integer"
                 },
@@ -429,10 +2835,224 @@ const docTemplate = `{
                 "posts_created_within_past": {
                     "$ref": "#/definitions/task.CreatedWithinPast"
                 },
+                "scraper_options": {
+                    "$ref": "#/definitions/task.ScraperOptions"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/task.Status"
+                },
                 "subreddit_name": {
                     "type": "string"
                 }
             }
+        },
+        "task.UpdateScraperOptionsRequestBody": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "scraper_options": {
+                    "$ref": "#/definitions/task.ScraperOptions"
+                }
+            }
+        },
+        "trending.ErrorResponse": {
+            "type": "object"
+        },
+        "trending.ListResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/trending.ListResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "trending.ListResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_noellimx_redditminer_src_controller_mux_trending.Event"
+                    }
+                }
+            }
+        },
+        "watchlist.ErrorResponse": {
+            "type": "object"
+        },
+        "watchlist.Keyword": {
+            "type": "object",
+            "properties": {
+                "case_sensitive": {
+                    "description": "defaults to case insensitive",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "ignored in requests",
+                    "type": "integer"
+                },
+                "is_regex": {
+                    "description": "RE2 syntax; otherwise matched as a whole phrase",
+                    "type": "boolean"
+                },
+                "keyword": {
+                    "type": "string"
+                }
+            }
+        },
+        "watchlist.KeywordSeries": {
+            "type": "object",
+            "properties": {
+                "best_rank": {
+                    "description": "null without matches",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "in_top_n": {
+                    "description": "distinct posts matched at or above the top n rank",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "keyword": {
+                    "$ref": "#/definitions/watchlist.Keyword"
+                },
+                "mentions": {
+                    "description": "distinct posts matched",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "watchlist.ListResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/watchlist.ListResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "watchlist.ListResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "watchlists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/watchlist.Watchlist"
+                    }
+                }
+            }
+        },
+        "watchlist.SeriesResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/watchlist.SeriesResponseBodyData"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "watchlist.SeriesResponseBodyData": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "keywords": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/watchlist.KeywordSeries"
+                    }
+                },
+                "top_n": {
+                    "type": "integer"
+                },
+                "watchlist": {
+                    "$ref": "#/definitions/watchlist.Watchlist"
+                }
+            }
+        },
+        "watchlist.Watchlist": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "keywords": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/watchlist.Keyword"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "subreddits": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "watchlist.WatchlistResponseBody": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/watchlist.Watchlist"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "watchlist.WriteRequestBody": {
+            "type": "object",
+            "properties": {
+                "keywords": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/watchlist.Keyword"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "subreddits": {
+                    "description": "optional, every subreddit when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
         }
     }
 }`
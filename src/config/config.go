@@ -3,8 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
@@ -18,12 +21,46 @@ type ServerConfig struct {
 }
 
 type DatabaseConfig struct {
-	ConnString string
+	ConnString       string
+	MigrateOnStartup bool // apply pending migrations before serving instead of refusing to start
+}
+
+type SchedulerConfig struct {
+	ReplicaId         string        // identifies this process in task runs and advisory lock reports
+	MissedRunLookback time.Duration // how far back missed windows are detected and caught up
+	RunTimeout        time.Duration // runs still running after this long are marked failed
+}
+
+type ScraperConfig struct {
+	SnapshotDir string // where tasks with snapshot capture enabled save their scrapes
+}
+
+type StatisticsConfig struct {
+	ConflictPolicy string // keep_first or keep_latest observation when a post is stored twice for the same poll bucket
+}
+
+// RetentionConfig is how long data is kept per granularity. Zero keeps it forever.
+type RetentionConfig struct {
+	Raw     time.Duration // raw observations, removed a whole monthly partition at a time
+	Hourly  time.Duration
+	Daily   time.Duration
+	Archive bool // move expired data to the archive schema instead of dropping it
+}
+
+type TrendingConfig struct {
+	ZScore     float64       // velocity z-score at or above which a post is trending
+	Baseline   time.Duration // history each subreddit's baseline is computed from
+	WebhookUrl string        // optional, receives new trending events
 }
 
 type Config struct {
 	DatabaseConfig
 	ServerConfig
+	SchedulerConfig
+	ScraperConfig
+	StatisticsConfig
+	RetentionConfig
+	TrendingConfig
 }
 
 func InitConfig() (c Config, e error) {
@@ -38,6 +75,87 @@ func InitConfig() (c Config, e error) {
 	// database
 	dbUrl := os.Getenv("DATABASE_URL")
 	c.DatabaseConfig.ConnString = dbUrl
+	c.DatabaseConfig.MigrateOnStartup = os.Getenv("MIGRATE_ON_STARTUP") == "TRUE"
+
+	// scheduler
+	c.SchedulerConfig.ReplicaId = os.Getenv("REPLICA_ID")
+	if c.SchedulerConfig.ReplicaId == "" {
+		hostname, _ := os.Hostname()
+		c.SchedulerConfig.ReplicaId = fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8])
+	}
+
+	c.SchedulerConfig.MissedRunLookback = 24 * time.Hour
+	if lookback := os.Getenv("MISSED_RUN_LOOKBACK"); lookback != "" {
+		c.SchedulerConfig.MissedRunLookback, err = time.ParseDuration(lookback)
+		if err != nil {
+			return Config{}, fmt.Errorf("error. MISSED_RUN_LOOKBACK=%s is not a duration: %w", lookback, err)
+		}
+	}
+
+	c.SchedulerConfig.RunTimeout = 30 * time.Minute
+	if timeout := os.Getenv("RUN_TIMEOUT"); timeout != "" {
+		c.SchedulerConfig.RunTimeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return Config{}, fmt.Errorf("error. RUN_TIMEOUT=%s is not a duration: %w", timeout, err)
+		}
+	}
+
+	// scraper
+	c.ScraperConfig.SnapshotDir = os.Getenv("SCRAPER_SNAPSHOT_DIR")
+	if c.ScraperConfig.SnapshotDir == "" {
+		c.ScraperConfig.SnapshotDir = "snapshots"
+	}
+
+	// statistics
+	c.StatisticsConfig.ConflictPolicy = os.Getenv("STATISTICS_CONFLICT_POLICY")
+	if c.StatisticsConfig.ConflictPolicy == "" {
+		c.StatisticsConfig.ConflictPolicy = "keep_first"
+	}
+	if c.StatisticsConfig.ConflictPolicy != "keep_first" && c.StatisticsConfig.ConflictPolicy != "keep_latest" {
+		return Config{}, fmt.Errorf("error. STATISTICS_CONFLICT_POLICY=%s is not keep_first or keep_latest", c.StatisticsConfig.ConflictPolicy)
+	}
+
+	// retention
+	for env, ttl := range map[string]*time.Duration{
+		"RETENTION_RAW":    &c.RetentionConfig.Raw,
+		"RETENTION_HOURLY": &c.RetentionConfig.Hourly,
+		"RETENTION_DAILY":  &c.RetentionConfig.Daily,
+	} {
+		if v := os.Getenv(env); v != "" {
+			*ttl, err = time.ParseDuration(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("error. %s=%s is not a duration: %w", env, v, err)
+			}
+		}
+	}
+	// the daily rollup is computed from raw observations of the whole day
+	if c.RetentionConfig.Raw != 0 && c.RetentionConfig.Raw < 48*time.Hour {
+		return Config{}, fmt.Errorf("error. RETENTION_RAW=%s is shorter than 48h", c.RetentionConfig.Raw)
+	}
+	switch mode := os.Getenv("RETENTION_MODE"); mode {
+	case "", "archive":
+		c.RetentionConfig.Archive = true
+	case "drop":
+	default:
+		return Config{}, fmt.Errorf("error. RETENTION_MODE=%s is not archive or drop", mode)
+	}
+
+	// trending
+	c.TrendingConfig.ZScore = 3
+	if z := os.Getenv("TRENDING_Z_SCORE"); z != "" {
+		c.TrendingConfig.ZScore, err = strconv.ParseFloat(z, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("error. TRENDING_Z_SCORE=%s is not a number: %w", z, err)
+		}
+	}
+	c.TrendingConfig.Baseline = 7 * 24 * time.Hour
+	if baseline := os.Getenv("TRENDING_BASELINE"); baseline != "" {
+		c.TrendingConfig.Baseline, err = time.ParseDuration(baseline)
+		if err != nil {
+			return Config{}, fmt.Errorf("error. TRENDING_BASELINE=%s is not a duration: %w", baseline, err)
+		}
+	}
+	c.TrendingConfig.WebhookUrl = os.Getenv("TRENDING_WEBHOOK_URL")
 
 	// server
 	return c, nil
@@ -0,0 +1,123 @@
+package analytics
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	analyticsservice "github.com/noellimx/redditminer/src/service/analytics"
+)
+
+// BestTime godoc
+// @Summary      When to post in a subreddit.
+// @Description  Buckets the posts created between from_time and to_time by their creation hour of the week in the timezone. For each bucket: posts, the probability of reaching the top n in the ranking context, and the median peak score.
+// @Description  JSON matrices are indexed by day, Monday first, then by hour. CSV has one row per day and hour.
+// @Tags         analytics
+// @Param        name   								path       string  true  "subreddit name"
+// @Param        rank_order_type   					query      string  true  "[top,best,hot,new]"
+// @Param        rank_order_created_within_past   	query      string  true  "[hour,day,month,year]"
+// @Param        from_time   						query      string  true  "post created from, 2006-01-02T15:04:05.000Z"
+// @Param        to_time   							query      string  true  "post created before, 2006-01-02T15:04:05.000Z"
+// @Param        top_n   							query      int     false "rank counted as in the top, default 10"
+// @Param        timezone   						query      string  false "IANA name, default UTC"
+// @Accept       json, text/csv
+// @Produce      json, text/csv
+// @Success      200  {object}  BestTimeResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Router       /subreddits/{name}/best-time [get]
+func (h Handlers) BestTime(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	s, err := scope(r, r.PathValue("name"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	topN, err := intQuery(r, "top_n", 10)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	loc, err := time.LoadLocation(r.URL.Query().Get("timezone"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("timezone: %w", err))
+		return
+	}
+
+	bt, err := h.service.BestTime(s, int32(topN), loc)
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, toBestTimeJSON(bt))
+	case "text/csv":
+		response_types.Csv(w, csvName(s, "best_time"), toBestTimeCSV(bt))
+	}
+}
+
+func toBestTimeCSV(bt analyticsservice.BestTime) [][]string {
+	rows := [][]string{{"day_of_week", "hour", "posts", "reached_top_n", "probability", "median_peak_score"}}
+	for day, weekday := range analyticsservice.Weekdays {
+		for hour, cell := range bt.Cells[day] {
+			rows = append(rows, []string{
+				weekday.String(),
+				strconv.Itoa(hour),
+				strconv.Itoa(cell.Posts),
+				strconv.Itoa(cell.ReachedTopN),
+				formatRate(cell.Probability),
+				formatFloat64(cell.MedianPeakScore),
+			})
+		}
+	}
+	return rows
+}
+
+func toBestTimeJSON(bt analyticsservice.BestTime) BestTimeResponseBodyData {
+	data := BestTimeResponseBodyData{
+		Timezone: bt.Location.String(),
+		TopN:     bt.TopN,
+	}
+	for hour := 0; hour < 24; hour++ {
+		data.Hours = append(data.Hours, hour)
+	}
+	for day, weekday := range analyticsservice.Weekdays {
+		data.Days = append(data.Days, weekday.String())
+		var posts []int
+		var probability, medianPeakScore []*float64
+		for _, cell := range bt.Cells[day] {
+			posts = append(posts, cell.Posts)
+			probability = append(probability, cell.Probability)
+			medianPeakScore = append(medianPeakScore, cell.MedianPeakScore)
+		}
+		data.Posts = append(data.Posts, posts)
+		data.Probability = append(data.Probability, probability)
+		data.MedianPeakScore = append(data.MedianPeakScore, medianPeakScore)
+	}
+	return data
+}
+
+// BestTimeResponseBodyData matrices are indexed [day][hour] along Days and Hours, null where there are no posts.
+type BestTimeResponseBodyData struct {
+	Timezone        string       `json:"timezone"`
+	TopN            int32        `json:"top_n"`
+	Days            []string     `json:"days"`
+	Hours           []int        `json:"hours"`
+	Posts           [][]int      `json:"posts"`
+	Probability     [][]*float64 `json:"probability"` // share of the posts reaching the top n
+	MedianPeakScore [][]*float64 `json:"median_peak_score"`
+}
+type BestTimeResponseBody = response_types.Response[BestTimeResponseBodyData]
@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+	analyticsservice "github.com/noellimx/redditminer/src/service/analytics"
+)
+
+// Compare godoc
+// @Summary      Compare subreddits on aligned time buckets.
+// @Description  For each subreddit and bucket: mean score and median comment count of the top n, turnover rate of the top n against the previous bucket, and average age in hours of the posts peaking in the bucket.
+// @Description  CSV is wide: one row per bucket and four columns per subreddit, prefixed with its name.
+// @Tags         analytics
+// @Param        subreddits   						query      string  true  "comma separated names, at most 10"
+// @Param        rank_order_type   					query      string  true  "[top,best,hot,new]"
+// @Param        rank_order_created_within_past   	query      string  true  "[hour,day,month,year]"
+// @Param        granularity   						query      string  true  "1=Minute,2=QuarterHour,3=Hour,4=Daily"
+// @Param        from_time   						query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        to_time   							query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        top_n   							query      int     false "rank counted as in the top, default 10"
+// @Accept       json, text/csv
+// @Produce      json, text/csv
+// @Success      200  {object}  CompareResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Router       /statistics/compare [get]
+func (h Handlers) Compare(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	s, err := scope(r, "")
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	granularity, err := intQuery(r, "granularity", 0)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	topN, err := intQuery(r, "top_n", 10)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	subreddits := strings.Split(r.URL.Query().Get("subreddits"), ",")
+
+	comparison, err := h.service.Compare(subreddits, s, statisticsrepo.Granularity(granularity), int32(topN))
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, toComparisonJSON(comparison, int32(topN)))
+	case "text/csv":
+		s.SubredditName = strings.Join(subreddits, "-")
+		response_types.Csv(w, csvName(s, "compare"), toComparisonCSV(comparison))
+	}
+}
+
+func toComparisonCSV(c analyticsservice.Comparison) [][]string {
+	header := []string{"bucket"}
+	for _, cs := range c.Series {
+		header = append(header,
+			fmt.Sprintf("%s_mean_score_top_n", cs.SubredditName),
+			fmt.Sprintf("%s_median_comments_top_n", cs.SubredditName),
+			fmt.Sprintf("%s_turnover_rate", cs.SubredditName),
+			fmt.Sprintf("%s_avg_post_age_at_peak_hours", cs.SubredditName),
+		)
+	}
+
+	rows := [][]string{header}
+	for i, t := range c.Buckets {
+		row := []string{t.UTC().String()}
+		for _, cs := range c.Series {
+			row = append(row,
+				formatFloat64(cs.MeanScoreTopN[i]),
+				formatFloat64(cs.MedianCommentsTopN[i]),
+				formatRate(cs.TurnoverRate[i]),
+				formatFloat64(cs.AvgPostAgeAtPeakHours[i]),
+			)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func formatRate(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 4, 64)
+}
+
+func toComparisonJSON(c analyticsservice.Comparison, topN int32) CompareResponseBodyData {
+	data := CompareResponseBodyData{
+		TopN:    topN,
+		Buckets: c.Buckets,
+	}
+	for _, cs := range c.Series {
+		data.Subreddits = append(data.Subreddits, CompareSeries{
+			SubredditName:         cs.SubredditName,
+			MeanScoreTopN:         cs.MeanScoreTopN,
+			MedianCommentsTopN:    cs.MedianCommentsTopN,
+			TurnoverRate:          cs.TurnoverRate,
+			AvgPostAgeAtPeakHours: cs.AvgPostAgeAtPeakHours,
+		})
+	}
+	return data
+}
+
+// CompareSeries values are aligned with CompareResponseBodyData.Buckets and null where there is no data.
+type CompareSeries struct {
+	SubredditName         string     `json:"subreddit_name"`
+	MeanScoreTopN         []*float64 `json:"mean_score_top_n"`
+	MedianCommentsTopN    []*float64 `json:"median_comments_top_n"`
+	TurnoverRate          []*float64 `json:"turnover_rate"` // share of the top n that was not in the top n of the previous bucket
+	AvgPostAgeAtPeakHours []*float64 `json:"avg_post_age_at_peak_hours"`
+}
+
+type CompareResponseBodyData struct {
+	TopN       int32           `json:"top_n"`
+	Buckets    []time.Time     `json:"buckets"`
+	Subreddits []CompareSeries `json:"subreddits"`
+}
+type CompareResponseBody = response_types.Response[CompareResponseBodyData]
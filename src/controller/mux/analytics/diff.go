@@ -0,0 +1,155 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+	analyticsservice "github.com/noellimx/redditminer/src/service/analytics"
+)
+
+// Diff godoc
+// @Summary      What changed in a ranking between two times.
+// @Description  Compares the nearest stored polls to the at and vs times: posts that entered, posts that dropped out, and posts that moved with their rank and score deltas. rank_delta is positive when the post moved up since vs.
+// @Description  CSV has one row per change, with change as entered, dropped or moved.
+// @Tags         analytics
+// @Param        subreddit   						query      string  true  "subreddit name"
+// @Param        rank_order_type   					query      string  true  "[top,best,hot,new]"
+// @Param        rank_order_created_within_past   	query      string  true  "[hour,day,month,year]"
+// @Param        at   								query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        vs   								query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        top_n   							query      int     false "only consider ranks up to n in either poll, default 0 for every rank"
+// @Accept       json, text/csv
+// @Produce      json, text/csv
+// @Success      200  {object}  DiffResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /statistics/diff [get]
+func (h Handlers) Diff(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	at, err := time.Parse(timeLayout, r.URL.Query().Get("at"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("at: %w", err))
+		return
+	}
+	vs, err := time.Parse(timeLayout, r.URL.Query().Get("vs"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("vs: %w", err))
+		return
+	}
+	topN, err := intQuery(r, "top_n", 0)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	subredditName := r.URL.Query().Get("subreddit")
+	orderType := r.URL.Query().Get("rank_order_type")
+	past := r.URL.Query().Get("rank_order_created_within_past")
+
+	diff, err := h.service.Diff(subredditName, orderType, past, at, vs, int32(topN))
+	if errors.Is(err, analyticsrepo.ErrNoPoll) {
+		response_types.ErrorNoBody(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, toDiffJSON(diff))
+	case "text/csv":
+		layout := "2006-01-02_15-04-05"
+		name := fmt.Sprintf(`%s_diff_%s_%s_AT_%s_VS_%s`, subredditName, orderType, past, diff.AtPoll.Format(layout), diff.VsPoll.Format(layout))
+		response_types.Csv(w, name, toDiffCSV(diff))
+	}
+}
+
+func toDiffCSV(d analyticsservice.Diff) [][]string {
+	rows := [][]string{{"change", "data_ks_id", "title", "perma_link_path", "author_name", "rank_at", "rank_vs", "rank_delta", "score_at", "score_vs", "score_delta"}}
+	for _, changes := range [][]analyticsservice.Change{d.Entered, d.Dropped, d.Movers} {
+		for _, c := range changes {
+			rows = append(rows, []string{
+				string(c.Type),
+				c.DataKsId,
+				c.Title,
+				c.PermaLinkPath,
+				c.AuthorName,
+				formatInt32(c.RankAt),
+				formatInt32(c.RankVs),
+				formatInt32(c.RankDelta),
+				formatInt32(c.ScoreAt),
+				formatInt32(c.ScoreVs),
+				formatInt32(c.ScoreDelta),
+			})
+		}
+	}
+	return rows
+}
+
+func toDiffJSON(d analyticsservice.Diff) DiffResponseBodyData {
+	toChanges := func(changes []analyticsservice.Change) []DiffChange {
+		out := []DiffChange{}
+		for _, c := range changes {
+			out = append(out, DiffChange{
+				DataKsId:      c.DataKsId,
+				Title:         c.Title,
+				PermaLinkPath: c.PermaLinkPath,
+				AuthorName:    c.AuthorName,
+				RankAt:        c.RankAt,
+				RankVs:        c.RankVs,
+				RankDelta:     c.RankDelta,
+				ScoreAt:       c.ScoreAt,
+				ScoreVs:       c.ScoreVs,
+				ScoreDelta:    c.ScoreDelta,
+			})
+		}
+		return out
+	}
+	return DiffResponseBodyData{
+		AtPoll:    d.AtPoll,
+		VsPoll:    d.VsPoll,
+		Entered:   toChanges(d.Entered),
+		Dropped:   toChanges(d.Dropped),
+		Movers:    toChanges(d.Movers),
+		Unchanged: d.Unchanged,
+	}
+}
+
+// DiffChange fields of the poll the post is missing from are null.
+type DiffChange struct {
+	DataKsId      string `json:"data_ks_id"`
+	Title         string `json:"title"`
+	PermaLinkPath string `json:"perma_link_path"`
+	AuthorName    string `json:"author_name"`
+	RankAt        *int32 `json:"rank_at"`
+	RankVs        *int32 `json:"rank_vs"`
+	RankDelta     *int32 `json:"rank_delta"`
+	ScoreAt       *int32 `json:"score_at"`
+	ScoreVs       *int32 `json:"score_vs"`
+	ScoreDelta    *int32 `json:"score_delta"`
+}
+
+type DiffResponseBodyData struct {
+	AtPoll    time.Time    `json:"at_poll"`
+	VsPoll    time.Time    `json:"vs_poll"`
+	Entered   []DiffChange `json:"entered"`
+	Dropped   []DiffChange `json:"dropped"`
+	Movers    []DiffChange `json:"movers"`
+	Unchanged int          `json:"unchanged"`
+}
+type DiffResponseBody = response_types.Response[DiffResponseBodyData]
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+)
+
+// Leaderboard godoc
+// @Summary      Rank the authors of a subreddit.
+// @Description  Ranks authors by how their posts placed in the ranking context over the time range: distinct posts reaching the top n, hours in the top n, best rank or peak score.
+// @Tags         analytics
+// @Param        name   								path       string  true  "subreddit name"
+// @Param        rank_order_type   					query      string  true  "[top,best,hot,new]"
+// @Param        rank_order_created_within_past   	query      string  true  "[hour,day,month,year]"
+// @Param        from_time   						query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        to_time   							query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        metric   							query      string  false "posts_in_top_n (default), hours_in_top_n, best_rank or peak_score"
+// @Param        top_n   							query      int     false "rank counted as in the top, default 10"
+// @Param        limit   							query      int     false "authors, default 50, at most 1000"
+// @Accept       json, text/csv
+// @Produce      json, text/csv
+// @Success      200  {object}  LeaderboardResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Router       /subreddits/{name}/authors/leaderboard [get]
+func (h Handlers) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	s, err := scope(r, r.PathValue("name"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	metric := analyticsrepo.LeaderboardMetric(r.URL.Query().Get("metric"))
+	if metric == "" {
+		metric = analyticsrepo.LeaderboardMetricPostsInTopN
+	}
+	topN, err := intQuery(r, "top_n", 10)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, err := intQuery(r, "limit", 50)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	standings, err := h.service.Leaderboard(s, metric, int32(topN), limit)
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, LeaderboardResponseBodyData{
+			Metric:  string(metric),
+			TopN:    int32(topN),
+			Authors: toAuthors(standings),
+		})
+	case "text/csv":
+		response_types.Csv(w, csvName(s, "authors_"+string(metric)), toAuthorsCSV(standings))
+	}
+}
+
+func toAuthorsCSV(standings []analyticsrepo.AuthorStanding) [][]string {
+	rows := [][]string{{
+		"position",
+		"author_id",
+		"author_name",
+		"posts",
+		"posts_in_top_n",
+		"hours_in_top_n",
+		"best_rank",
+		"peak_score",
+	}}
+	for i, a := range standings {
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			a.AuthorId,
+			a.AuthorName,
+			strconv.FormatInt(a.Posts, 10),
+			strconv.FormatInt(a.PostsInTopN, 10),
+			strconv.FormatInt(a.HoursInTopN, 10),
+			strconv.FormatInt(int64(a.BestRank), 10),
+			formatInt32(a.PeakScore),
+		})
+	}
+	return rows
+}
+
+func toAuthors(standings []analyticsrepo.AuthorStanding) []Author {
+	aa := []Author{}
+	for i, a := range standings {
+		aa = append(aa, Author{
+			Position:    i + 1,
+			AuthorId:    a.AuthorId,
+			AuthorName:  a.AuthorName,
+			Posts:       a.Posts,
+			PostsInTopN: a.PostsInTopN,
+			HoursInTopN: a.HoursInTopN,
+			BestRank:    a.BestRank,
+			PeakScore:   a.PeakScore,
+		})
+	}
+	return aa
+}
+
+type Author struct {
+	Position    int    `json:"position"`
+	AuthorId    string `json:"author_id"`
+	AuthorName  string `json:"author_name"`
+	Posts       int64  `json:"posts"`          // distinct posts observed
+	PostsInTopN int64  `json:"posts_in_top_n"` // distinct posts reaching the top n
+	HoursInTopN int64  `json:"hours_in_top_n"` // distinct post hours observed in the top n
+	BestRank    int32  `json:"best_rank"`
+	PeakScore   *int32 `json:"peak_score"`
+}
+
+type LeaderboardResponseBodyData struct {
+	Metric  string   `json:"metric"`
+	TopN    int32    `json:"top_n"`
+	Authors []Author `json:"authors"`
+}
+type LeaderboardResponseBody = response_types.Response[LeaderboardResponseBodyData]
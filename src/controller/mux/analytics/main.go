@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	analyticsservice "github.com/noellimx/redditminer/src/service/analytics"
+)
+
+// timeLayout is the layout of from_time and to_time, as for /statistics.
+const timeLayout = "2006-01-02T15:04:05.000Z"
+
+type Handlers struct {
+	service *analyticsservice.Service
+}
+
+func NewHandlers(service *analyticsservice.Service) *Handlers {
+	return &Handlers{
+		service: service,
+	}
+}
+
+// scope reads the ranking context and time range of the query, for the subreddit.
+func scope(r *http.Request, subredditName string) (analyticsservice.Scope, error) {
+	from, err := time.Parse(timeLayout, r.URL.Query().Get("from_time"))
+	if err != nil {
+		return analyticsservice.Scope{}, fmt.Errorf("from_time: %w", err)
+	}
+	to, err := time.Parse(timeLayout, r.URL.Query().Get("to_time"))
+	if err != nil {
+		return analyticsservice.Scope{}, fmt.Errorf("to_time: %w", err)
+	}
+	return analyticsservice.Scope{
+		SubredditName: subredditName,
+		OrderType:     r.URL.Query().Get("rank_order_type"),
+		Past:          r.URL.Query().Get("rank_order_created_within_past"),
+		From:          from,
+		To:            to,
+	}, nil
+}
+
+// intQuery parses an optional integer query parameter.
+func intQuery(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return i, nil
+}
+
+func csvName(s analyticsservice.Scope, what string) string {
+	layout := "2006-01-02_15-04-05"
+	return fmt.Sprintf(`%s_%s_%s_%s_FROM_%s_TO_%s`, s.SubredditName, what, s.OrderType, s.Past, s.From.Format(layout), s.To.Format(layout))
+}
+
+func formatInt32(v *int32) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+func formatFloat64(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 2, 64)
+}
+
+type ErrorResponse = response_types.Response[struct{}]
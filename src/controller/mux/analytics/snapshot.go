@@ -0,0 +1,166 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+	analyticsservice "github.com/noellimx/redditminer/src/service/analytics"
+)
+
+// Snapshot godoc
+// @Summary      The ranked listing of a subreddit as stored at a point in time.
+// @Description  Returns the stored poll nearest to at, with its exact poll time and the task run that polled it.
+// @Description  text/plain is a compact rendering for chat: a header line, then one line per post.
+// @Tags         analytics
+// @Param        subreddit   query      string  true  "subreddit name"
+// @Param        order   	 query      string  true  "[top,best,hot,new]"
+// @Param        past   	 query      string  true  "[hour,day,month,year]"
+// @Param        at   		 query      string  true  "2006-01-02T15:04:05.000Z"
+// @Accept       json, text/csv, text/plain
+// @Produce      json, text/csv, text/plain
+// @Success      200  {object}  SnapshotResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /snapshot [get]
+func (h Handlers) Snapshot(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	contentType := r.Header.Get("Accept")
+	if contentType != "text/plain" {
+		var err error
+		contentType, err = response_types.Negotiate(r)
+		if err != nil {
+			response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+			return
+		}
+	}
+
+	at, err := time.Parse(timeLayout, r.URL.Query().Get("at"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("at: %w", err))
+		return
+	}
+
+	snapshot, err := h.service.Snapshot(r.URL.Query().Get("subreddit"), r.URL.Query().Get("order"), r.URL.Query().Get("past"), at)
+	if errors.Is(err, analyticsrepo.ErrNoPoll) {
+		response_types.ErrorNoBody(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, toSnapshotJSON(snapshot))
+	case "text/csv":
+		name := fmt.Sprintf(`%s_snapshot_%s_%s_AT_%s`, snapshot.SubredditName, snapshot.OrderType, snapshot.Past, snapshot.PolledTime.Format("2006-01-02_15-04-05"))
+		response_types.Csv(w, name, toSnapshotCSV(snapshot))
+	case "text/plain":
+		response_types.Text(w, toSnapshotText(snapshot))
+	}
+}
+
+func toSnapshotCSV(s analyticsservice.Snapshot) [][]string {
+	rows := [][]string{{"rank", "data_ks_id", "title", "perma_link_path", "author_name", "score", "comment_count", "post_created_at", "polled_time", "run_id"}}
+	for _, p := range s.Posts {
+		createdAt := ""
+		if p.PostCreatedAt != nil {
+			createdAt = p.PostCreatedAt.UTC().String()
+		}
+		runId := ""
+		if p.RunId != nil {
+			runId = fmt.Sprint(*p.RunId)
+		}
+		rows = append(rows, []string{
+			formatInt32(&p.Rank),
+			p.DataKsId,
+			p.Title,
+			p.PermaLinkPath,
+			p.AuthorName,
+			formatInt32(p.Score),
+			formatInt32(p.CommentCount),
+			createdAt,
+			p.PolledTime.UTC().String(),
+			runId,
+		})
+	}
+	return rows
+}
+
+func toSnapshotText(s analyticsservice.Snapshot) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "r/%s %s/%s at %s UTC", s.SubredditName, s.OrderType, s.Past, s.PolledTime.UTC().Format("2006-01-02 15:04:05"))
+	if s.RunId != nil {
+		fmt.Fprintf(&sb, " run %d", *s.RunId)
+	}
+	sb.WriteString("\n")
+	for _, p := range s.Posts {
+		fmt.Fprintf(&sb, "%2d. %s", p.Rank, p.Title)
+		if p.Score != nil {
+			fmt.Fprintf(&sb, " [%d pts", *p.Score)
+			if p.CommentCount != nil {
+				fmt.Fprintf(&sb, ", %d comments", *p.CommentCount)
+			}
+			sb.WriteString("]")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func toSnapshotJSON(s analyticsservice.Snapshot) SnapshotResponseBodyData {
+	data := SnapshotResponseBodyData{
+		SubredditName: s.SubredditName,
+		OrderType:     s.OrderType,
+		Past:          s.Past,
+		PolledTime:    s.PolledTime,
+		Bucket:        s.Bucket,
+		RunId:         s.RunId,
+		Posts:         []SnapshotPost{},
+	}
+	for _, p := range s.Posts {
+		data.Posts = append(data.Posts, SnapshotPost{
+			Rank:          p.Rank,
+			DataKsId:      p.DataKsId,
+			Title:         p.Title,
+			PermaLinkPath: p.PermaLinkPath,
+			AuthorName:    p.AuthorName,
+			Score:         p.Score,
+			CommentCount:  p.CommentCount,
+			PostCreatedAt: p.PostCreatedAt,
+		})
+	}
+	return data
+}
+
+type SnapshotPost struct {
+	Rank          int32      `json:"rank"`
+	DataKsId      string     `json:"data_ks_id"`
+	Title         string     `json:"title"`
+	PermaLinkPath string     `json:"perma_link_path"`
+	AuthorName    string     `json:"author_name"`
+	Score         *int32     `json:"score"`
+	CommentCount  *int32     `json:"comment_count"`
+	PostCreatedAt *time.Time `json:"post_created_at"`
+}
+
+type SnapshotResponseBodyData struct {
+	SubredditName string         `json:"subreddit_name"`
+	OrderType     string         `json:"rank_order_type"`
+	Past          string         `json:"rank_order_created_within_past"`
+	PolledTime    time.Time      `json:"polled_time"`
+	Bucket        time.Time      `json:"polled_time_rounded_min"`
+	RunId         *int64         `json:"run_id"`
+	Posts         []SnapshotPost `json:"posts"`
+}
+type SnapshotResponseBody = response_types.Response[SnapshotResponseBodyData]
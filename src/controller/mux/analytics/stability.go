@@ -0,0 +1,132 @@
+package analytics
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	analyticsservice "github.com/noellimx/redditminer/src/service/analytics"
+)
+
+// Stability godoc
+// @Summary      Measure how sticky the top n of a subreddit is.
+// @Description  For each poll against the previous one: posts that entered and left the top n, average tenure in the top n, and the Spearman and Kendall rank correlations over the posts ranked in both polls. Averages over the range are included.
+// @Description  CSV responses have one row per poll.
+// @Tags         analytics
+// @Param        name   								path       string  true  "subreddit name"
+// @Param        rank_order_type   					query      string  true  "[top,best,hot,new]"
+// @Param        rank_order_created_within_past   	query      string  true  "[hour,day,month,year]"
+// @Param        from_time   						query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        to_time   							query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        top_n   							query      int     false "rank counted as in the top, default 10"
+// @Accept       json, text/csv
+// @Produce      json, text/csv
+// @Success      200  {object}  StabilityResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Router       /subreddits/{name}/stability [get]
+func (h Handlers) Stability(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	s, err := scope(r, r.PathValue("name"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	topN, err := intQuery(r, "top_n", 10)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stability, err := h.service.Stability(s, int32(topN))
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, toStabilityJSON(stability, int32(topN)))
+	case "text/csv":
+		response_types.Csv(w, csvName(s, "stability"), toStabilityCSV(stability))
+	}
+}
+
+func toStabilityCSV(stability analyticsservice.Stability) [][]string {
+	rows := [][]string{{
+		"polled_time_rounded_min",
+		"entered",
+		"left",
+		"avg_tenure_hours",
+		"common_posts",
+		"spearman",
+		"kendall",
+	}}
+	for _, p := range stability.Polls {
+		rows = append(rows, []string{
+			p.Bucket.UTC().String(),
+			strconv.Itoa(p.Entered),
+			strconv.Itoa(p.Left),
+			formatFloat64(p.AvgTenureHours),
+			strconv.Itoa(p.CommonPosts),
+			formatRate(p.Spearman),
+			formatRate(p.Kendall),
+		})
+	}
+	return rows
+}
+
+func toStabilityJSON(stability analyticsservice.Stability, topN int32) StabilityResponseBodyData {
+	data := StabilityResponseBodyData{
+		TopN:           topN,
+		AvgEntered:     stability.AvgEntered,
+		AvgLeft:        stability.AvgLeft,
+		AvgTenureHours: stability.AvgTenureHours,
+		AvgSpearman:    stability.AvgSpearman,
+		AvgKendall:     stability.AvgKendall,
+		Polls:          []PollStability{},
+	}
+	for _, p := range stability.Polls {
+		data.Polls = append(data.Polls, PollStability{
+			PolledTimeRoundedMinute: p.Bucket,
+			Entered:                 p.Entered,
+			Left:                    p.Left,
+			AvgTenureHours:          p.AvgTenureHours,
+			CommonPosts:             p.CommonPosts,
+			Spearman:                p.Spearman,
+			Kendall:                 p.Kendall,
+		})
+	}
+	return data
+}
+
+type PollStability struct {
+	PolledTimeRoundedMinute time.Time `json:"polled_time_rounded_min"`
+	Entered                 int       `json:"entered"` // into the top n since the previous poll
+	Left                    int       `json:"left"`    // the top n since the previous poll
+	AvgTenureHours          *float64  `json:"avg_tenure_hours"`
+	CommonPosts             int       `json:"common_posts"` // ranked in both polls, the correlations are over these
+	Spearman                *float64  `json:"spearman"`
+	Kendall                 *float64  `json:"kendall"`
+}
+
+type StabilityResponseBodyData struct {
+	TopN           int32           `json:"top_n"`
+	AvgEntered     *float64        `json:"avg_entered"`
+	AvgLeft        *float64        `json:"avg_left"`
+	AvgTenureHours *float64        `json:"avg_tenure_hours"`
+	AvgSpearman    *float64        `json:"avg_spearman"`
+	AvgKendall     *float64        `json:"avg_kendall"`
+	Polls          []PollStability `json:"polls"`
+}
+type StabilityResponseBody = response_types.Response[StabilityResponseBodyData]
@@ -0,0 +1,170 @@
+package analytics
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+	analyticsservice "github.com/noellimx/redditminer/src/service/analytics"
+)
+
+// Terms godoc
+// @Summary      Title terms of a subreddit over time.
+// @Description  Tokenizes the titles of the posts in the top n per bucket (lower case, stopwords removed) into n-grams and weighs each by count, reciprocal rank or score.
+// @Description  Trending terms gained share in the latest bucket with posts against their average share in the earlier buckets.
+// @Description  CSV has one row per bucket and term of the heaviest terms.
+// @Tags         analytics
+// @Param        name   								path       string  true  "subreddit name"
+// @Param        rank_order_type   					query      string  true  "[top,best,hot,new]"
+// @Param        rank_order_created_within_past   	query      string  true  "[hour,day,month,year]"
+// @Param        granularity   						query      string  true  "1=Minute,2=QuarterHour,3=Hour,4=Daily"
+// @Param        from_time   						query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        to_time   							query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        top_n   							query      int     false "ranks whose titles are counted, default 25"
+// @Param        n   								query      int     false "longest n-gram in words, default 2, at most 3"
+// @Param        weight   							query      string  false "count (default), rank or score"
+// @Param        limit   							query      int     false "terms and trending terms, default 50, at most 1000"
+// @Accept       json, text/csv
+// @Produce      json, text/csv
+// @Success      200  {object}  TermsResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Router       /subreddits/{name}/terms [get]
+func (h Handlers) Terms(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	s, err := scope(r, r.PathValue("name"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	granularity, err := intQuery(r, "granularity", 0)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	topN, err := intQuery(r, "top_n", 25)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	n, err := intQuery(r, "n", 2)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	weight := analyticsservice.TermWeight(r.URL.Query().Get("weight"))
+	if weight == "" {
+		weight = analyticsservice.TermWeightCount
+	}
+	limit, err := intQuery(r, "limit", 50)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	terms, err := h.service.Terms(s, statisticsrepo.Granularity(granularity), int32(topN), n, weight, limit)
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, toTermsJSON(terms, weight))
+	case "text/csv":
+		response_types.Csv(w, csvName(s, "terms_"+string(weight)), toTermsCSV(terms))
+	}
+}
+
+func toTermsCSV(t analyticsservice.Terms) [][]string {
+	trending := make(map[string]bool)
+	for _, tt := range t.Trending {
+		trending[tt.Term] = true
+	}
+
+	rows := [][]string{{"bucket", "term", "n", "weight", "share", "trending"}}
+	for i, bucket := range t.Buckets {
+		for _, ts := range t.Terms {
+			if ts.Shares[i] == nil {
+				continue
+			}
+			rows = append(rows, []string{
+				bucket.UTC().String(),
+				ts.Term,
+				strconv.Itoa(ts.N),
+				strconv.FormatFloat(ts.Weights[i], 'f', 4, 64),
+				formatRate(ts.Shares[i]),
+				strconv.FormatBool(trending[ts.Term]),
+			})
+		}
+	}
+	return rows
+}
+
+func toTermsJSON(t analyticsservice.Terms, weight analyticsservice.TermWeight) TermsResponseBodyData {
+	data := TermsResponseBodyData{
+		Weight:   string(weight),
+		Buckets:  t.Buckets,
+		Latest:   t.Latest,
+		Terms:    []Term{},
+		Trending: []TrendingTerm{},
+	}
+	for _, ts := range t.Terms {
+		data.Terms = append(data.Terms, Term{
+			Term:    ts.Term,
+			N:       ts.N,
+			Posts:   ts.Posts,
+			Weight:  ts.Weight,
+			Weights: ts.Weights,
+			Shares:  ts.Shares,
+		})
+	}
+	for _, tt := range t.Trending {
+		data.Trending = append(data.Trending, TrendingTerm{
+			Term:          tt.Term,
+			Posts:         tt.Posts,
+			Share:         tt.Share,
+			BaselineShare: tt.BaselineShare,
+			Lift:          tt.Lift,
+		})
+	}
+	return data
+}
+
+// Term series are aligned with TermsResponseBodyData.Buckets. Shares are null for buckets without posts.
+type Term struct {
+	Term    string     `json:"term"`
+	N       int        `json:"n"`
+	Posts   int        `json:"posts"`
+	Weight  float64    `json:"weight"`
+	Weights []float64  `json:"weights"`
+	Shares  []*float64 `json:"shares"`
+}
+
+type TrendingTerm struct {
+	Term          string   `json:"term"`
+	Posts         int      `json:"posts"` // in the latest bucket
+	Share         float64  `json:"share"`
+	BaselineShare float64  `json:"baseline_share"`
+	Lift          *float64 `json:"lift"` // null for terms new in the latest bucket
+}
+
+type TermsResponseBodyData struct {
+	Weight   string         `json:"weight"`
+	Buckets  []time.Time    `json:"buckets"`
+	Latest   *time.Time     `json:"latest"` // bucket trending terms are detected in
+	Terms    []Term         `json:"terms"`
+	Trending []TrendingTerm `json:"trending"`
+}
+type TermsResponseBody = response_types.Response[TermsResponseBodyData]
@@ -0,0 +1,143 @@
+package retention
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	retentionrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/retention"
+	retentionservice "github.com/noellimx/redditminer/src/service/retention"
+)
+
+// actionsLimit is how many of the latest actions Get reports.
+const actionsLimit = 100
+
+type Handlers struct {
+	service *retentionservice.Service
+}
+
+func NewHandlers(service *retentionservice.Service) *Handlers {
+	return &Handlers{
+		service: service,
+	}
+}
+
+// Get godoc
+// @Summary      Report observation partitions and retention work.
+// @Description  Lists the retention policy, the monthly partitions of post_observations and the latest partition and retention actions of every replica.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  GetResponseBody
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/retention [get]
+func (h Handlers) Get(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+	report, err := h.service.Report(actionsLimit)
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusInternalServerError, err)
+		return
+	}
+	response_types.OkJsonBody(w, GetResponseBodyData{
+		Policy:     toPolicy(h.service.Policy()),
+		Partitions: toPartitions(report.Partitions),
+		Actions:    toActions(report.Actions),
+	})
+}
+
+// Run godoc
+// @Summary      Run retention now.
+// @Description  Creates the upcoming partitions and expires data past the retention policy, as the hourly job does. Returns the actions taken, none when another replica is running it.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  RunResponseBody
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/retention/run [post]
+func (h Handlers) Run(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+	actions, err := h.service.Run()
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusInternalServerError, err)
+		return
+	}
+	response_types.OkJsonBody(w, RunResponseBodyData{
+		Actions: toActions(actions),
+	})
+}
+
+func toPolicy(p retentionservice.Policy) Policy {
+	mode := "drop"
+	if p.Archive {
+		mode = "archive"
+	}
+	return Policy{
+		Raw:    p.Raw.String(),
+		Hourly: p.Hourly.String(),
+		Daily:  p.Daily.String(),
+		Mode:   mode,
+	}
+}
+
+func toPartitions(partitions []retentionrepo.Partition) (pp []Partition) {
+	for _, p := range partitions {
+		pp = append(pp, Partition{
+			Name: p.Name,
+			From: p.From,
+			To:   p.To,
+		})
+	}
+	return
+}
+
+func toActions(actions []retentionrepo.Action) (aa []Action) {
+	for _, a := range actions {
+		aa = append(aa, Action{
+			RanAt:     a.RanAt,
+			ReplicaId: a.ReplicaId,
+			Action:    string(a.Action),
+			Target:    a.Target,
+			Rows:      a.Rows,
+		})
+	}
+	return
+}
+
+type Policy struct {
+	Raw    string `json:"raw"` // 0s keeps the data forever
+	Hourly string `json:"hourly"`
+	Daily  string `json:"daily"`
+	Mode   string `json:"mode"` // drop or archive
+}
+
+type Partition struct {
+	Name string    `json:"name"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type Action struct {
+	RanAt     time.Time `json:"ran_at"`
+	ReplicaId string    `json:"replica_id"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Rows      *int64    `json:"rows"`
+}
+
+type GetResponseBodyData struct {
+	Policy     Policy      `json:"policy"`
+	Partitions []Partition `json:"partitions"`
+	Actions    []Action    `json:"actions"`
+}
+
+type RunResponseBodyData struct {
+	Actions []Action `json:"actions"`
+}
+
+type GetResponseBody = response_types.Response[GetResponseBodyData]
+type RunResponseBody = response_types.Response[RunResponseBodyData]
+type ErrorResponse = response_types.Response[struct{}]
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	schedulerrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/scheduler"
+	schedulerservice "github.com/noellimx/redditminer/src/service/scheduler"
+)
+
+type Handlers struct {
+	service *schedulerservice.Service
+}
+
+func NewHandlers(service *schedulerservice.Service) *Handlers {
+	return &Handlers{
+		service: service,
+	}
+}
+
+// Locks godoc
+// @Summary      Report scheduled work held by replicas.
+// @Description  Lists the task locks held across the cluster and the runs in progress, with the replica holding each.
+// @Tags         scheduler
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  LocksResponseBody
+// @Failure      500  {object}  ErrorResponse
+// @Router       /scheduler/locks [get]
+func (h Handlers) Locks(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+	holders, err := h.service.Holders()
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusInternalServerError, err)
+		return
+	}
+	response_types.OkJsonBody(w, LocksResponseBodyData{
+		ReplicaId: h.service.ReplicaId(),
+		Locks:     toLocks(holders.Locks),
+		Runs:      toRuns(holders.Runs),
+	})
+}
+
+func toLocks(holders []schedulerrepo.LockHolder) (ll []Lock) {
+	for _, l := range holders {
+		ll = append(ll, Lock{
+			TaskId:       l.TaskId,
+			ReplicaId:    l.ApplicationName,
+			Pid:          l.Pid,
+			BackendStart: l.BackendStart,
+		})
+	}
+	return
+}
+
+func toRuns(runs []schedulerrepo.Run) (rr []Run) {
+	for _, r := range runs {
+		rr = append(rr, Run{
+			Id:          r.Id,
+			TaskId:      r.TaskId,
+			ScheduledAt: r.ScheduledAt,
+			ReplicaId:   r.ReplicaId,
+			Status:      string(r.Status),
+			StartedAt:   r.StartedAt,
+			FinishedAt:  r.FinishedAt,
+			Error:       r.Error,
+		})
+	}
+	return
+}
+
+type Lock struct {
+	TaskId       int64     `json:"task_id"`
+	ReplicaId    string    `json:"replica_id"` // application_name of the session holding the lock
+	Pid          int32     `json:"pid"`
+	BackendStart time.Time `json:"backend_start"`
+}
+
+type Run struct {
+	Id          int64      `json:"id"`
+	TaskId      int64      `json:"task_id"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	ReplicaId   string     `json:"replica_id"`
+	Status      string     `json:"status"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	Error       *string    `json:"error"`
+}
+
+type LocksResponseBodyData struct {
+	ReplicaId string `json:"replica_id"` // replica serving this request
+	Locks     []Lock `json:"locks"`
+	Runs      []Run  `json:"runs"`
+}
+
+type LocksResponseBody = response_types.Response[LocksResponseBodyData]
+type ErrorResponse = response_types.Response[struct{}]
@@ -26,13 +26,22 @@ func NewHandlers(service *statisticsservice.Service) *Handlers {
 
 // Get godoc
 // @Summary      Retrieve time series data in denormalized form.
-// @Description  Retrieve time series data in denormalized form.
+// @Description  Retrieve time series data in denormalized form, ordered by bucket, rank and post.
+// @Description  Without page_size and cursor every row is returned. Pages hold whole buckets and are walked with the opaque next_cursor, returned in the body for JSON and in the X-Next-Cursor header for CSV.
+// @Description  The rows matching the filter across every page are counted in total, or the X-Total-Count header for CSV.
 // @Tags         subreddit
 // @Param        subreddit_name   					query      string  true  "name"
 // @Param        rank_order_type   					query      string  true  "[top,best,hot,new]"
 // @Param        rank_order_created_within_past   	query      string  true  "[hour,day,month,year]"
-// @Param        granularity   						query      string  true  "1=Minute,2=QuarterHour,3=Hour,4=Daily,5=Monthly"
-// @Param        backfill   						query      string  true  "true=backfill incomplete data"
+// @Param        granularity   						query      string  true  "1=Minute,2=QuarterHour,3=Hour,4=Daily. Hour and Daily are read from rollups and carry min/max/avg aggregates"
+// @Param        backfill   						query      string  false "fills the buckets between from_time and to_time a post was not observed in: none (default), null, locf, linear or drop. true is null"
+// @Param        min_coverage   					query      number  false "for backfill=drop, the share of buckets a post must be observed in, default 0.5"
+// @Param        min_rank   						query      int     false "best rank to include, default 1"
+// @Param        max_rank   						query      int     false "worst rank to include, default 20"
+// @Param        page_size   						query      int     false "rows per page, at most 10000; unpaged when omitted, default 1000 with a cursor. A page holds whole buckets, at least one"
+// @Param        cursor   							query      string  false "next_cursor of the previous page"
+// @Param        derived   							query      string  false "true=add score and comment deltas and velocities, rank delta, hours since created and hours in top n"
+// @Param        top_n   							query      int     false "rank counted as in the top for hours in top n, default 10"
 // @Accept       json, text/csv
 // @Produce      json, text/csv
 // @Success      200  {object}  GetStatisticsResponseBody
@@ -48,11 +57,11 @@ func (h Handlers) Get(w http.ResponseWriter, r *http.Request) {
 	_fromTime := r.URL.Query().Get("from_time")
 	_toTime := r.URL.Query().Get("to_time")
 	_shouldBackfill := r.URL.Query().Get("backfill")
+	cursor := r.URL.Query().Get("cursor")
 
-	contentType := r.Header.Get("Accept")
-
-	if contentType != "application/json" && contentType != "text/csv" {
-		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, fmt.Errorf("content type %s not supported", contentType))
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
 		return
 	}
 
@@ -69,7 +78,21 @@ func (h Handlers) Get(w http.ResponseWriter, r *http.Request) {
 	}
 	fmt.Printf("TIME from %s to %s \n", fromTime, toTime)
 
-	backfill := _shouldBackfill == "true"
+	backfill := statisticsservice.BackfillStrategy(_shouldBackfill)
+	switch _shouldBackfill {
+	case "", "false":
+		backfill = statisticsservice.BackfillNone
+	case "true":
+		backfill = statisticsservice.BackfillNull
+	}
+	minCoverage := 0.5
+	if _minCoverage := r.URL.Query().Get("min_coverage"); _minCoverage != "" {
+		minCoverage, err = strconv.ParseFloat(_minCoverage, 64)
+		if err != nil {
+			response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("min_coverage: %w", err))
+			return
+		}
+	}
 	switch "" {
 	case _subRedditName, _rankOrderType, _rankOrderCreatedWithinPast, _granularity:
 		err := fmt.Errorf("some field is empty. subreddit_name %v, rank_order_type %v, rank_order_created_within_past %v, granularity %v", _subRedditName, _rankOrderType, _rankOrderCreatedWithinPast, _granularity)
@@ -80,7 +103,50 @@ func (h Handlers) Get(w http.ResponseWriter, r *http.Request) {
 
 	granularity, _ := strconv.Atoi(_granularity)
 
-	posts, err := h.service.Stats(_subRedditName, statisticsrepo.OrderByAlgo(_rankOrderType), statisticsrepo.CreatedWithinPast(_rankOrderCreatedWithinPast), statisticsrepo.Granularity(granularity), &fromTime, &toTime, backfill)
+	minRank, err := intQuery(r, "min_rank", 1)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	maxRank, err := intQuery(r, "max_rank", statisticsservice.DefaultMaxRank)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	// unpaged unless asked, as before paging existed
+	defaultPageSize := 0
+	if cursor != "" {
+		defaultPageSize = statisticsservice.DefaultPageSize
+	}
+	pageSize, err := intQuery(r, "page_size", defaultPageSize)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	topN, err := intQuery(r, "top_n", statisticsservice.DefaultTopN)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	derived := r.URL.Query().Get("derived") == "true"
+
+	page, err := h.service.Stats(statisticsservice.StatsQuery{
+		Name:        _subRedditName,
+		OrderType:   statisticsrepo.OrderByAlgo(_rankOrderType),
+		Past:        statisticsrepo.CreatedWithinPast(_rankOrderCreatedWithinPast),
+		Granularity: statisticsrepo.Granularity(granularity),
+		FromTime:    &fromTime,
+		ToTime:      &toTime,
+		Backfill:    backfill,
+		MinCoverage: minCoverage,
+		MinRank:     int32(minRank),
+		MaxRank:     int32(maxRank),
+		Cursor:      cursor,
+		PageSize:    pageSize,
+		Derived:     derived,
+		TopN:        int32(topN),
+	})
 	if err != nil {
 		log.Printf("%s error=%v\n", prefix, err)
 		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
@@ -90,7 +156,9 @@ func (h Handlers) Get(w http.ResponseWriter, r *http.Request) {
 	switch contentType {
 	case "application/json":
 		response_types.OkJsonBody(w, GetStatisticsResponseBodyData{
-			Posts: toJSON(posts),
+			Posts:      toJSON(page.Posts),
+			Total:      page.Total,
+			NextCursor: page.NextCursor,
 		})
 	case "text/csv":
 		layout := "2006-01-02_15-04-05"
@@ -98,11 +166,28 @@ func (h Handlers) Get(w http.ResponseWriter, r *http.Request) {
 		ttString := toTime.Format(layout)
 
 		csvName := fmt.Sprintf(`%s_%s_%s_FROM_%s_TO_%s`, _subRedditName, _rankOrderType, _rankOrderCreatedWithinPast, ftString, ttString)
-		response_types.Csv(w, csvName, toCSV(posts))
+		w.Header().Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+		if page.NextCursor != "" {
+			w.Header().Set("X-Next-Cursor", page.NextCursor)
+		}
+		response_types.Csv(w, csvName, toCSV(page.Posts, derived))
 	}
 }
 
-func toCSV(posts []statisticsservice.Post) [][]string {
+// intQuery parses an optional integer query parameter.
+func intQuery(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return i, nil
+}
+
+func toCSV(posts []statisticsservice.Post, derived bool) [][]string {
 	header := []string{
 		"row_position",
 
@@ -123,6 +208,27 @@ func toCSV(posts []statisticsservice.Post) [][]string {
 		"subreddit_name",
 		"author_id",
 		"author_name",
+
+		"min_rank",
+		"max_rank",
+		"avg_rank",
+		"min_score",
+		"max_score",
+		"avg_score",
+
+		"is_synthetic",
+		"backfill_method",
+	}
+	if derived {
+		header = append(header,
+			"score_delta",
+			"comment_delta",
+			"score_velocity",
+			"comment_velocity",
+			"rank_delta",
+			"hours_since_created",
+			"hours_in_top_n",
+		)
 	}
 
 	rows := [][]string{header}
@@ -143,7 +249,7 @@ func toCSV(posts []statisticsservice.Post) [][]string {
 			rank = strconv.FormatInt(int64(*p.Rank), 10)
 		}
 
-		rows = append(rows, []string{
+		row := []string{
 			strconv.Itoa(i),
 
 			p.PolledTime.UTC().String(),
@@ -165,11 +271,48 @@ func toCSV(posts []statisticsservice.Post) [][]string {
 
 			p.AuthorId,
 			p.AuthorName,
-		})
+
+			formatInt32(p.MinRank),
+			formatInt32(p.MaxRank),
+			formatFloat64(p.AvgRank),
+			formatInt32(p.MinScore),
+			formatInt32(p.MaxScore),
+			formatFloat64(p.AvgScore),
+
+			strconv.FormatBool(p.IsSynthetic),
+			string(p.BackfillMethod),
+		}
+		if derived && p.Derived != nil {
+			hoursInTopN := p.Derived.HoursInTopN
+			row = append(row,
+				formatInt32(p.Derived.ScoreDelta),
+				formatInt32(p.Derived.CommentDelta),
+				formatFloat64(p.Derived.ScoreVelocity),
+				formatFloat64(p.Derived.CommentVelocity),
+				formatInt32(p.Derived.RankDelta),
+				formatFloat64(p.Derived.HoursSinceCreated),
+				formatFloat64(&hoursInTopN),
+			)
+		}
+		rows = append(rows, row)
 	}
 	return rows
 }
 
+func formatInt32(v *int32) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+func formatFloat64(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 2, 64)
+}
+
 func toJSON(posts []statisticsservice.Post) (ps []Post) {
 	for _, post := range posts {
 		ps = append(ps, Post{
@@ -188,6 +331,14 @@ func toJSON(posts []statisticsservice.Post) (ps []Post) {
 			RankOrderType:                 post.RankOrderType,
 			RankOrderForCreatedWithinPast: post.RankOrderForCreatedWithinPast,
 			IsSynthetic:                   post.IsSynthetic,
+			BackfillMethod:                string(post.BackfillMethod),
+			MinRank:                       post.MinRank,
+			MaxRank:                       post.MaxRank,
+			AvgRank:                       post.AvgRank,
+			MinScore:                      post.MinScore,
+			MaxScore:                      post.MaxScore,
+			AvgScore:                      post.AvgScore,
+			Derived:                       toDerived(post.Derived),
 		})
 	}
 	return
@@ -211,11 +362,49 @@ type Post struct {
 	RankOrderType                 statisticsrepo.OrderByAlgo       `json:"rank_order_type"`
 	RankOrderForCreatedWithinPast statisticsrepo.CreatedWithinPast `json:"rank_order_created_within_past"`
 
-	IsSynthetic bool `json:"is_synthetic"`
+	IsSynthetic    bool   `json:"is_synthetic"`
+	BackfillMethod string `json:"backfill_method,omitempty"` // null, locf or linear for synthetic points
+
+	// bucket aggregates, set for rollup granularities
+	MinRank  *int32   `json:"min_rank"`
+	MaxRank  *int32   `json:"max_rank"`
+	AvgRank  *float64 `json:"avg_rank"`
+	MinScore *int32   `json:"min_score"`
+	MaxScore *int32   `json:"max_score"`
+	AvgScore *float64 `json:"avg_score"`
+
+	Derived *Derived `json:"derived,omitempty"` // with derived=true
+}
+
+type Derived struct {
+	ScoreDelta        *int32   `json:"score_delta"`
+	CommentDelta      *int32   `json:"comment_delta"`
+	ScoreVelocity     *float64 `json:"score_velocity"`   // per hour
+	CommentVelocity   *float64 `json:"comment_velocity"` // per hour
+	RankDelta         *int32   `json:"rank_delta"`       // positive when the post moved up
+	HoursSinceCreated *float64 `json:"hours_since_created"`
+	HoursInTopN       float64  `json:"hours_in_top_n"`
+}
+
+func toDerived(d *statisticsservice.Derived) *Derived {
+	if d == nil {
+		return nil
+	}
+	return &Derived{
+		ScoreDelta:        d.ScoreDelta,
+		CommentDelta:      d.CommentDelta,
+		ScoreVelocity:     d.ScoreVelocity,
+		CommentVelocity:   d.CommentVelocity,
+		RankDelta:         d.RankDelta,
+		HoursSinceCreated: d.HoursSinceCreated,
+		HoursInTopN:       d.HoursInTopN,
+	}
 }
 
 type GetStatisticsResponseBodyData struct {
-	Posts []Post `json:"posts"`
+	Posts      []Post `json:"posts"`
+	Total      int64  `json:"total"`       // rows matching the filter across every page
+	NextCursor string `json:"next_cursor"` // empty on the last page
 }
 type GetStatisticsResponseBody = response_types.Response[GetStatisticsResponseBodyData]
 type ErrorResponse = response_types.Response[struct{}]
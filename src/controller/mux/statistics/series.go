@@ -0,0 +1,185 @@
+package statistics
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+	statisticsservice "github.com/noellimx/redditminer/src/service/statistics"
+)
+
+// Series godoc
+// @Summary      Retrieve one post's time series across ranking contexts.
+// @Description  Returns the post's rank, score and comment count at every poll, in every ranking context it appeared in, ordered by poll.
+// @Description  Summaries give the first-seen, last-seen and peak rank overall and per ranking context.
+// @Description  CSV responses carry the overall summary in the X-First-Seen, X-Last-Seen, X-Peak-Rank and X-Peak-Rank-At headers.
+// @Tags         post
+// @Param        data_ks_id   path      string  true  "post id, e.g. t3_abc123"
+// @Accept       json, text/csv
+// @Produce      json, text/csv
+// @Success      200  {object}  GetSeriesResponseBody
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /posts/{data_ks_id}/series [get]
+func (h Handlers) Series(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	dataKsId := r.PathValue("data_ks_id")
+
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	series, err := h.service.PostSeries(dataKsId)
+	if errors.Is(err, statisticsrepo.ErrPostNotFound) {
+		response_types.ErrorNoBody(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, toSeriesJSON(series))
+	case "text/csv":
+		w.Header().Set("X-First-Seen", series.FirstSeenAt.UTC().Format(time.RFC3339))
+		w.Header().Set("X-Last-Seen", series.LastSeenAt.UTC().Format(time.RFC3339))
+		if series.PeakRank != nil {
+			w.Header().Set("X-Peak-Rank", strconv.FormatInt(int64(*series.PeakRank), 10))
+			w.Header().Set("X-Peak-Rank-At", series.PeakRankAt.UTC().Format(time.RFC3339))
+		}
+		response_types.Csv(w, fmt.Sprintf("%s_series", dataKsId), toSeriesCSV(series))
+	}
+}
+
+func toSeriesCSV(series statisticsservice.Series) [][]string {
+	rows := [][]string{{
+		"polled_time",
+		"polled_time_rounded_min",
+
+		"rank_order_type",
+		"rank_order_created_within_past",
+
+		"rank",
+		"score",
+		"comment_count",
+	}}
+	for _, p := range series.Points {
+		rows = append(rows, []string{
+			p.PolledTime.UTC().String(),
+			p.PolledTimeRoundedMinute.UTC().String(),
+
+			string(p.RankOrderType),
+			string(p.RankOrderForCreatedWithinPast),
+
+			strconv.FormatInt(int64(p.Rank), 10),
+			formatInt32(p.Score),
+			formatInt32(p.CommentCount),
+		})
+	}
+	return rows
+}
+
+func toSeriesJSON(series statisticsservice.Series) GetSeriesResponseBodyData {
+	data := GetSeriesResponseBodyData{
+		DataKsId:      series.Post.DataKsId,
+		Title:         series.Post.Title,
+		PermaLinkPath: series.Post.PermaLinkPath,
+		SubredditId:   series.Post.SubredditId,
+		SubredditName: series.Post.SubredditName,
+		AuthorId:      series.Post.AuthorId,
+		AuthorName:    series.Post.AuthorName,
+		PostCreatedAt: series.Post.PostCreatedAt,
+
+		FirstSeenAt: series.FirstSeenAt,
+		LastSeenAt:  series.LastSeenAt,
+		PeakRank:    series.PeakRank,
+		PeakRankAt:  series.PeakRankAt,
+	}
+	if series.PeakContext != nil {
+		data.PeakRankOrderType = series.PeakContext.RankOrderType
+		data.PeakRankOrderForCreatedWithinPast = series.PeakContext.RankOrderForCreatedWithinPast
+	}
+	for _, c := range series.Contexts {
+		data.Contexts = append(data.Contexts, SeriesContext{
+			RankOrderType:                 c.RankOrderType,
+			RankOrderForCreatedWithinPast: c.RankOrderForCreatedWithinPast,
+			Observations:                  c.Observations,
+			FirstSeenAt:                   c.FirstSeenAt,
+			LastSeenAt:                    c.LastSeenAt,
+			PeakRank:                      c.PeakRank,
+			PeakRankAt:                    c.PeakRankAt,
+			PeakScore:                     c.PeakScore,
+		})
+	}
+	for _, p := range series.Points {
+		data.Points = append(data.Points, SeriesPoint{
+			PolledTime:                    p.PolledTime,
+			PolledTimeRoundedMinute:       p.PolledTimeRoundedMinute,
+			RankOrderType:                 p.RankOrderType,
+			RankOrderForCreatedWithinPast: p.RankOrderForCreatedWithinPast,
+			Rank:                          p.Rank,
+			Score:                         p.Score,
+			CommentCount:                  p.CommentCount,
+		})
+	}
+	return data
+}
+
+type SeriesContext struct {
+	RankOrderType                 statisticsrepo.OrderByAlgo       `json:"rank_order_type"`
+	RankOrderForCreatedWithinPast statisticsrepo.CreatedWithinPast `json:"rank_order_created_within_past"`
+
+	Observations int       `json:"observations"`
+	FirstSeenAt  time.Time `json:"first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+	PeakRank     int32     `json:"peak_rank"`
+	PeakRankAt   time.Time `json:"peak_rank_at"` // first poll at the peak rank
+	PeakScore    *int32    `json:"peak_score"`
+}
+
+type SeriesPoint struct {
+	PolledTime                    time.Time                        `json:"polled_time"`
+	PolledTimeRoundedMinute       time.Time                        `json:"polled_time_rounded_min"`
+	RankOrderType                 statisticsrepo.OrderByAlgo       `json:"rank_order_type"`
+	RankOrderForCreatedWithinPast statisticsrepo.CreatedWithinPast `json:"rank_order_created_within_past"`
+
+	Rank         int32  `json:"rank"`
+	Score        *int32 `json:"score"`
+	CommentCount *int32 `json:"comment_count"`
+}
+
+type GetSeriesResponseBodyData struct {
+	DataKsId      string     `json:"data_ks_id"`
+	Title         string     `json:"title"`
+	PermaLinkPath string     `json:"perma_link_path"`
+	SubredditId   string     `json:"subreddit_id"`
+	SubredditName string     `json:"subreddit_name"`
+	AuthorId      string     `json:"author_id"`
+	AuthorName    string     `json:"author_name"`
+	PostCreatedAt *time.Time `json:"post_created_at"`
+
+	// over every ranking context
+	FirstSeenAt                       time.Time                        `json:"first_seen_at"`
+	LastSeenAt                        time.Time                        `json:"last_seen_at"`
+	PeakRank                          *int32                           `json:"peak_rank"`
+	PeakRankAt                        *time.Time                       `json:"peak_rank_at"`
+	PeakRankOrderType                 statisticsrepo.OrderByAlgo       `json:"peak_rank_order_type"`
+	PeakRankOrderForCreatedWithinPast statisticsrepo.CreatedWithinPast `json:"peak_rank_order_created_within_past"`
+
+	Contexts []SeriesContext `json:"contexts"`
+	Points   []SeriesPoint   `json:"points"`
+}
+type GetSeriesResponseBody = response_types.Response[GetSeriesResponseBodyData]
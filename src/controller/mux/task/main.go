@@ -2,8 +2,10 @@ package task
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/noellimx/redditminer/src/controller/response_types"
 	"github.com/noellimx/redditminer/src/httplog"
@@ -11,11 +13,22 @@ import (
 	taskservice "github.com/noellimx/redditminer/src/service/task"
 )
 
+// Service is the task service the handlers serve.
+type Service interface {
+	Create(d taskservice.Definition, validate bool) error
+	UpdateScraperOptions(id int64, options taskrepo.ScraperOptions) error
+	Delete(id int64) error
+	GetTasks() ([]taskrepo.Task, error)
+	GetMissedWindows(interval taskrepo.Granularity, lookback time.Duration) ([]taskrepo.MissedWindow, error)
+	Export() ([]taskservice.Definition, error)
+	Import(definitions []taskservice.Definition, prune bool, dryRun bool) ([]taskservice.Change, error)
+}
+
 type Handlers struct {
-	service *taskservice.Service
+	service Service
 }
 
-func NewHandlers(service *taskservice.Service) *Handlers {
+func NewHandlers(service Service) *Handlers {
 	return &Handlers{
 		service: service,
 	}
@@ -27,6 +40,25 @@ type CreateRequestBody struct {
 	Interval               string `json:"interval"`                  // to be executed every interval ["hour"]
 	OrderBy                string `json:"order_by"`                  // ["top", "hot", "best", "new"]
 	ItemsCreatedWithinPast string `json:"posts_created_within_past"` // ["day","hour","month","year"]
+	CatchUpPolicy          string `json:"catch_up_policy"`           // ["skip", "once", "all"] applied on startup to missed windows, defaults to skip
+
+	StartsAt *time.Time `json:"starts_at"` // optional, not scheduled before
+	EndsAt   *time.Time `json:"ends_at"`   // optional, completed once reached
+	MaxRuns  *int64     `json:"max_runs"`  // optional, completed after this many windows of the interval with a succeeded run
+
+	ScraperOptions ScraperOptions `json:"scraper_options"` // optional
+
+	ValidateSubreddit bool `json:"validate_subreddit"` // check the subreddit exists and is not private or banned before creating
+}
+
+type ScraperOptions struct {
+	ScrollStrategy    string `json:"scroll_strategy,omitempty" yaml:"scroll_strategy,omitempty"`         // ["none", "once", "until_count"] defaults to once; until_count scrolls until min_item_count posts are loaded
+	ScrollWaitSeconds *int   `json:"scroll_wait_seconds,omitempty" yaml:"scroll_wait_seconds,omitempty"` // wait after each scroll, defaults to 10, 0 for none
+	MaxScrolls        int    `json:"max_scrolls,omitempty" yaml:"max_scrolls,omitempty"`                 // bound for until_count, defaults to 1
+	MaxItems          int    `json:"max_items,omitempty" yaml:"max_items,omitempty"`                     // posts ranked beyond are dropped, 0 keeps all
+	DebugLogEnabled   bool   `json:"debug_log_enabled,omitempty" yaml:"debug_log_enabled,omitempty"`
+	CaptureSnapshot   bool   `json:"capture_snapshot,omitempty" yaml:"capture_snapshot,omitempty"` // save a screenshot and the html of each scrape
+	Retries           int    `json:"retries,omitempty" yaml:"retries,omitempty"`                   // extra attempts when a scrape yields no posts
 }
 
 // Create godoc
@@ -37,14 +69,73 @@ type CreateRequestBody struct {
 // @Produce      json
 // @Param        request body CreateRequestBody true "Create Request Body"
 // @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
 // @Router       /task [post]
 func (h Handlers) Create(w http.ResponseWriter, r *http.Request) {
 	prefix := httplog.SPrintHttpRequestPrefix(r)
 	form := &CreateRequestBody{}
-	json.NewDecoder(r.Body).Decode(form)
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err := h.service.Create(taskservice.Definition{
+		SubredditName:          form.SubredditName,
+		MinItemCount:           form.MinItemCount,
+		Interval:               form.Interval,
+		OrderBy:                form.OrderBy,
+		PostsCreatedWithinPast: form.ItemsCreatedWithinPast,
+		CatchUpPolicy:          form.CatchUpPolicy,
+		Limits: taskrepo.Limits{
+			StartsAt: form.StartsAt,
+			EndsAt:   form.EndsAt,
+			MaxRuns:  form.MaxRuns,
+		},
+		ScraperOptions: taskrepo.ScraperOptions(form.ScraperOptions),
+	}, form.ValidateSubreddit)
+	if errors.Is(err, taskrepo.ErrDuplicate) {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	response_types.OkJsonBody(w, struct {
+	}{})
+}
+
+type UpdateScraperOptionsRequestBody struct {
+	Id             int64          `json:"id"`
+	ScraperOptions ScraperOptions `json:"scraper_options"`
+}
+
+// UpdateScraperOptions godoc
+// @Summary      Tune the scraper of a task
+// @Description  Replace the scraper options of a task. They apply from its next run.
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request body UpdateScraperOptionsRequestBody true "Update Scraper Options Request Body"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /task/scraper_options [put]
+func (h Handlers) UpdateScraperOptions(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+	form := &UpdateScraperOptionsRequestBody{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
 
-	err := h.service.Create(form.SubredditName, form.MinItemCount, form.Interval, form.OrderBy, form.ItemsCreatedWithinPast)
+	err := h.service.UpdateScraperOptions(form.Id, taskrepo.ScraperOptions(form.ScraperOptions))
 	if err != nil {
 		log.Printf("%s error=%v\n", prefix, err)
 		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
@@ -66,12 +157,17 @@ type DeleteRequestBody struct {
 // @Produce      json
 // @Param        request body DeleteRequestBody true "Delete Request Body"
 // @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
 // @Router       /task [delete]
 func (h Handlers) Delete(w http.ResponseWriter, r *http.Request) {
 	prefix := httplog.SPrintHttpRequestPrefix(r)
 	form := &DeleteRequestBody{}
-	json.NewDecoder(r.Body).Decode(form)
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
 
 	err := h.service.Delete(form.Id)
 	if err != nil {
@@ -105,6 +201,49 @@ func (h Handlers) List(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Missed godoc
+// @Summary      Get missed task windows.
+// @Description  Get the fully elapsed windows within the lookback where a task has no succeeded run.
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        lookback   query      string  false  "Go duration, defaults to 24h"
+// @Success      200  {object}  MissedResponseBodyData
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tasks/missed [get]
+func (h Handlers) Missed(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	lookback := 24 * time.Hour
+	if _lookback := r.URL.Query().Get("lookback"); _lookback != "" {
+		var err error
+		lookback, err = time.ParseDuration(_lookback)
+		if err != nil {
+			log.Printf("%s error=%v\n", prefix, err)
+			response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	missed, err := h.service.GetMissedWindows(taskrepo.GranularityHour, lookback)
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var windows []MissedWindow
+	for _, m := range missed {
+		windows = append(windows, MissedWindow{
+			TaskId:      m.TaskId,
+			WindowStart: m.WindowStart,
+		})
+	}
+	response_types.OkJsonBody(w, MissedResponseBodyData{
+		Windows: windows,
+	})
+}
+
 func toResponse(tasks []taskrepo.Task) (tt []Task) {
 	for _, t := range tasks {
 		tt = append(tt, Task{
@@ -114,6 +253,12 @@ func toResponse(tasks []taskrepo.Task) (tt []Task) {
 			Interval:               Granularity(t.Interval),
 			OrderBy:                OrderByAlgo(t.OrderBy),
 			PostsCreatedWithinPast: CreatedWithinPast(t.PostsCreatedWithinPast),
+			CatchUpPolicy:          CatchUpPolicy(t.CatchUpPolicy),
+			StartsAt:               t.StartsAt,
+			EndsAt:                 t.EndsAt,
+			MaxRuns:                t.MaxRuns,
+			Status:                 Status(t.Status),
+			ScraperOptions:         ScraperOptions(t.ScraperOptions),
 		})
 	}
 	return
@@ -143,6 +288,21 @@ const (
 	CreatedWithinPastYear  CreatedWithinPast = "year"
 )
 
+type CatchUpPolicy string
+
+const (
+	CatchUpPolicySkip CatchUpPolicy = "skip"
+	CatchUpPolicyOnce CatchUpPolicy = "once"
+	CatchUpPolicyAll  CatchUpPolicy = "all"
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusCompleted Status = "completed"
+)
+
 type Task struct {
 	Id                     int64             `json:"id"`
 	SubRedditName          string            `json:"subreddit_name"`
@@ -150,10 +310,25 @@ type Task struct {
 	Interval               Granularity       `json:"interval"`
 	OrderBy                OrderByAlgo       `json:"order_by"`
 	PostsCreatedWithinPast CreatedWithinPast `json:"posts_created_within_past"`
+	CatchUpPolicy          CatchUpPolicy     `json:"catch_up_policy"`
+	StartsAt               *time.Time        `json:"starts_at"`
+	EndsAt                 *time.Time        `json:"ends_at"`
+	MaxRuns                *int64            `json:"max_runs"`
+	Status                 Status            `json:"status"`
+	ScraperOptions         ScraperOptions    `json:"scraper_options"`
 }
 
 type ListResponseBodyData struct {
 	Tasks []Task `json:"tasks"`
 }
 
+type MissedWindow struct {
+	TaskId      int64     `json:"task_id"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+type MissedResponseBodyData struct {
+	Windows []MissedWindow `json:"windows"`
+}
+
 type ErrorResponse = response_types.Response[struct{}]
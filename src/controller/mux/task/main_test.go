@@ -0,0 +1,92 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	taskrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/task"
+	taskservice "github.com/noellimx/redditminer/src/service/task"
+)
+
+// stubService records the definitions created and returns err from Create and Delete.
+type stubService struct {
+	err     error
+	created []taskservice.Definition
+}
+
+var _ Service = (*stubService)(nil)
+
+func (s *stubService) Create(d taskservice.Definition, validate bool) error {
+	s.created = append(s.created, d)
+	return s.err
+}
+
+func (s *stubService) UpdateScraperOptions(id int64, options taskrepo.ScraperOptions) error {
+	return s.err
+}
+
+func (s *stubService) Delete(id int64) error {
+	return s.err
+}
+
+func (s *stubService) GetTasks() ([]taskrepo.Task, error) {
+	return nil, s.err
+}
+
+func (s *stubService) GetMissedWindows(interval taskrepo.Granularity, lookback time.Duration) ([]taskrepo.MissedWindow, error) {
+	return nil, s.err
+}
+
+func (s *stubService) Export() ([]taskservice.Definition, error) {
+	return nil, s.err
+}
+
+func (s *stubService) Import(definitions []taskservice.Definition, prune bool, dryRun bool) ([]taskservice.Change, error) {
+	return nil, s.err
+}
+
+func TestCreateStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		err     error
+		want    int
+		created bool
+	}{
+		{"created", `{"subreddit_name":"golang"}`, nil, http.StatusOK, true},
+		{"duplicate", `{"subreddit_name":"golang"}`, taskrepo.ErrDuplicate, http.StatusConflict, true},
+		{"wrapped duplicate", `{"subreddit_name":"golang"}`, fmt.Errorf("create: %w", taskrepo.ErrDuplicate), http.StatusConflict, true},
+		{"validation error", `{"subreddit_name":""}`, errors.New("some invalid params"), http.StatusBadRequest, true},
+		{"subreddit not found", `{"subreddit_name":"golang","validate_subreddit":true}`, errors.New("subreddit golang does not exist"), http.StatusBadRequest, true},
+		{"malformed body", `{"subreddit_name":`, nil, http.StatusBadRequest, false},
+		{"wrong type", `{"min_item_count":"ten"}`, nil, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubService{err: tt.err}
+			w := httptest.NewRecorder()
+			NewHandlers(service).Create(w, httptest.NewRequest(http.MethodPost, "/task", strings.NewReader(tt.body)))
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := len(service.created) == 1; got != tt.created {
+				t.Fatalf("created = %v, want %v", got, tt.created)
+			}
+		})
+	}
+}
+
+func TestDeleteRejectsMalformedBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewHandlers(&stubService{}).Delete(w, httptest.NewRequest(http.MethodDelete, "/task", strings.NewReader(`{"id":`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
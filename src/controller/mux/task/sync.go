@@ -0,0 +1,202 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	taskrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/task"
+	taskservice "github.com/noellimx/redditminer/src/service/task"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Definition is a task in an export document. Import takes the same shape.
+type Definition struct {
+	SubredditName          string     `json:"subreddit_name" yaml:"subreddit_name"`
+	MinItemCount           int64      `json:"min_item_count" yaml:"min_item_count"`
+	Interval               string     `json:"interval" yaml:"interval"`
+	OrderBy                string     `json:"order_by" yaml:"order_by"`
+	PostsCreatedWithinPast string     `json:"posts_created_within_past" yaml:"posts_created_within_past"`
+	CatchUpPolicy          string     `json:"catch_up_policy,omitempty" yaml:"catch_up_policy,omitempty"`
+	StartsAt               *time.Time `json:"starts_at,omitempty" yaml:"starts_at,omitempty"`
+	EndsAt                 *time.Time `json:"ends_at,omitempty" yaml:"ends_at,omitempty"`
+	MaxRuns                *int64     `json:"max_runs,omitempty" yaml:"max_runs,omitempty"`
+
+	ScraperOptions ScraperOptions `json:"scraper_options" yaml:"scraper_options"`
+}
+
+type Document struct {
+	Tasks []Definition `json:"tasks" yaml:"tasks"`
+}
+
+func isYaml(mediaType string) bool {
+	return strings.Contains(mediaType, "yaml")
+}
+
+// Export godoc
+// @Summary      Export active tasks.
+// @Description  Export the active tasks as a declarative document that POST /tasks/import accepts.
+// @Tags         task
+// @Param        format   query      string  false  "[json,yaml] defaults to json, or taken from the Accept header"
+// @Produce      json, application/yaml
+// @Success      200  {object}  Document
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tasks/export [get]
+func (h Handlers) Export(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	definitions, err := h.service.Export()
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	doc := Document{Tasks: []Definition{}}
+	for _, d := range definitions {
+		doc.Tasks = append(doc.Tasks, Definition{
+			SubredditName:          d.SubredditName,
+			MinItemCount:           d.MinItemCount,
+			Interval:               d.Interval,
+			OrderBy:                d.OrderBy,
+			PostsCreatedWithinPast: d.PostsCreatedWithinPast,
+			CatchUpPolicy:          d.CatchUpPolicy,
+			StartsAt:               d.StartsAt,
+			EndsAt:                 d.EndsAt,
+			MaxRuns:                d.MaxRuns,
+			ScraperOptions:         ScraperOptions(d.ScraperOptions),
+		})
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && isYaml(r.Header.Get("Accept")) {
+		format = "yaml"
+	}
+
+	switch format {
+	case "yaml":
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			response_types.ErrorNoBody(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Content-Disposition", "attachment; filename=tasks.yaml")
+		w.Write(b)
+	case "", "json":
+		b, _ := json.MarshalIndent(doc, "", "  ")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", "attachment; filename=tasks.json")
+		w.Write(b)
+	default:
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("format %s not supported", format))
+	}
+}
+
+// Import godoc
+// @Summary      Import tasks.
+// @Description  Upsert the tasks of a declarative document, keyed on subreddit, interval, order by and posts created within past. Body is JSON, or YAML with a yaml Content-Type.
+// @Tags         task
+// @Accept       json, application/yaml
+// @Produce      json
+// @Param        request body Document true "Task document"
+// @Param        dry_run   query      string  false  "true=return the plan without applying it"
+// @Param        prune   query      string  false  "true=delete active tasks missing from the document"
+// @Success      200  {object}  ImportResponseBodyData
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tasks/import [post]
+func (h Handlers) Import(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var doc Document
+	if isYaml(r.Header.Get("Content-Type")) {
+		err = yaml.Unmarshal(body, &doc)
+	} else {
+		err = json.Unmarshal(body, &doc)
+	}
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var definitions []taskservice.Definition
+	for _, d := range doc.Tasks {
+		definitions = append(definitions, taskservice.Definition{
+			SubredditName:          d.SubredditName,
+			MinItemCount:           d.MinItemCount,
+			Interval:               d.Interval,
+			OrderBy:                d.OrderBy,
+			PostsCreatedWithinPast: d.PostsCreatedWithinPast,
+			CatchUpPolicy:          d.CatchUpPolicy,
+			Limits: taskrepo.Limits{
+				StartsAt: d.StartsAt,
+				EndsAt:   d.EndsAt,
+				MaxRuns:  d.MaxRuns,
+			},
+			ScraperOptions: taskrepo.ScraperOptions(d.ScraperOptions),
+		})
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	prune := r.URL.Query().Get("prune") == "true"
+	changes, err := h.service.Import(definitions, prune, dryRun)
+	if errors.Is(err, taskrepo.ErrDuplicate) {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var cc []Change
+	for _, c := range changes {
+		change := Change{
+			Action: string(c.Action),
+			Key:    c.Key,
+		}
+		if c.Before != nil {
+			before := toResponse([]taskrepo.Task{*c.Before})[0]
+			change.Before = &before
+		}
+		if c.After != nil {
+			after := toResponse([]taskrepo.Task{*c.After})[0]
+			change.After = &after
+		}
+		cc = append(cc, change)
+	}
+	response_types.OkJsonBody(w, ImportResponseBodyData{
+		DryRun:  dryRun,
+		Changes: cc,
+	})
+}
+
+type Change struct {
+	Action string `json:"action"` // ["create", "update", "delete", "unchanged"]
+	Key    string `json:"key"`
+	Before *Task  `json:"before"`
+	After  *Task  `json:"after"`
+}
+
+type ImportResponseBodyData struct {
+	DryRun  bool     `json:"dry_run"`
+	Changes []Change `json:"changes"`
+}
@@ -0,0 +1,100 @@
+package trending
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	trendingrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/trending"
+	trendingservice "github.com/noellimx/redditminer/src/service/trending"
+)
+
+const (
+	defaultSince = 24 * time.Hour
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Handlers struct {
+	service *trendingservice.Service
+}
+
+func NewHandlers(service *trendingservice.Service) *Handlers {
+	return &Handlers{
+		service: service,
+	}
+}
+
+// List godoc
+// @Summary      List trending posts of a subreddit.
+// @Description  Posts whose score or rank velocity reached the trending z-score against the subreddit's baseline after a scrape, newest first.
+// @Tags         trending
+// @Param        subreddit   query      string  true   "name"
+// @Param        since       query      string  false  "how far back, a Go duration, default 24h"
+// @Param        limit       query      int     false  "at most this many events, default 100, at most 1000"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  ListResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /trending [get]
+func (h Handlers) List(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	subreddit := r.URL.Query().Get("subreddit")
+	if subreddit == "" {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("subreddit is empty"))
+		return
+	}
+
+	since := defaultSince
+	if _since := r.URL.Query().Get("since"); _since != "" {
+		var err error
+		since, err = time.ParseDuration(_since)
+		if err != nil {
+			response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	limit := defaultLimit
+	if _limit := r.URL.Query().Get("limit"); _limit != "" {
+		var err error
+		limit, err = strconv.Atoi(_limit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("limit %s not supported, need 1 to %d", _limit, maxLimit))
+			return
+		}
+	}
+
+	events, err := h.service.Events(subreddit, time.Now().UTC().Add(-since), limit)
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusInternalServerError, err)
+		return
+	}
+	response_types.OkJsonBody(w, ListResponseBodyData{
+		Events: toEvents(events),
+	})
+}
+
+func toEvents(events []trendingrepo.Event) []Event {
+	ee := []Event{}
+	for _, e := range events {
+		ee = append(ee, Event(e))
+	}
+	return ee
+}
+
+type Event trendingrepo.Event
+
+type ListResponseBodyData struct {
+	Events []Event `json:"events"`
+}
+
+type ListResponseBody = response_types.Response[ListResponseBodyData]
+type ErrorResponse = response_types.Response[struct{}]
@@ -0,0 +1,379 @@
+package watchlist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/noellimx/redditminer/src/controller/response_types"
+	"github.com/noellimx/redditminer/src/httplog"
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+	watchlistrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/watchlist"
+	watchlistservice "github.com/noellimx/redditminer/src/service/watchlist"
+)
+
+// timeLayout is the layout of from_time and to_time, as for /statistics.
+const timeLayout = "2006-01-02T15:04:05.000Z"
+
+type Handlers struct {
+	service *watchlistservice.Service
+}
+
+func NewHandlers(service *watchlistservice.Service) *Handlers {
+	return &Handlers{
+		service: service,
+	}
+}
+
+type Keyword struct {
+	Id            int64  `json:"id"` // ignored in requests
+	Keyword       string `json:"keyword"`
+	IsRegex       bool   `json:"is_regex"`       // RE2 syntax; otherwise matched as a whole phrase
+	CaseSensitive bool   `json:"case_sensitive"` // defaults to case insensitive
+}
+
+type WriteRequestBody struct {
+	Name       string    `json:"name"`
+	Subreddits []string  `json:"subreddits"` // optional, every subreddit when empty
+	Keywords   []Keyword `json:"keywords"`
+}
+
+func (b WriteRequestBody) toDefinition() watchlistservice.Definition {
+	d := watchlistservice.Definition{
+		Name:       b.Name,
+		Subreddits: b.Subreddits,
+	}
+	for _, k := range b.Keywords {
+		d.Keywords = append(d.Keywords, watchlistrepo.Keyword{Keyword: k.Keyword, IsRegex: k.IsRegex, CaseSensitive: k.CaseSensitive})
+	}
+	return d
+}
+
+// pathId reads the watchlist id of the path.
+func pathId(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("id: %w", err)
+	}
+	return id, nil
+}
+
+// writeError maps the errors of the watchlist repo to their status.
+func writeError(w http.ResponseWriter, prefix string, err error) {
+	log.Printf("%s error=%v\n", prefix, err)
+	switch {
+	case errors.Is(err, watchlistrepo.ErrNotFound):
+		response_types.ErrorNoBody(w, http.StatusNotFound, err)
+	case errors.Is(err, watchlistrepo.ErrDuplicate):
+		response_types.ErrorNoBody(w, http.StatusConflict, err)
+	default:
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+	}
+}
+
+// Create godoc
+// @Summary      Create a keyword watchlist.
+// @Description  Keywords are matched against the title of every post scraped from then on, in the listed subreddits or in every subreddit.
+// @Tags         watchlist
+// @Accept       json
+// @Produce      json
+// @Param        request body WriteRequestBody true "Watchlist"
+// @Success      200  {object}  WatchlistResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /watchlists [post]
+func (h Handlers) Create(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+	form := &WriteRequestBody{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	watchlist, err := h.service.Create(form.toDefinition())
+	if err != nil {
+		writeError(w, prefix, err)
+		return
+	}
+	response_types.OkJsonBody(w, toWatchlist(watchlist))
+}
+
+// Update godoc
+// @Summary      Replace a keyword watchlist.
+// @Description  Matches of the keywords kept are kept, matches of the removed keywords are deleted.
+// @Tags         watchlist
+// @Accept       json
+// @Produce      json
+// @Param        id   	 path       int  true  "watchlist id"
+// @Param        request body WriteRequestBody true "Watchlist"
+// @Success      200  {object}  WatchlistResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /watchlists/{id} [put]
+func (h Handlers) Update(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+	id, err := pathId(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	form := &WriteRequestBody{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	watchlist, err := h.service.Update(id, form.toDefinition())
+	if err != nil {
+		writeError(w, prefix, err)
+		return
+	}
+	response_types.OkJsonBody(w, toWatchlist(watchlist))
+}
+
+// Delete godoc
+// @Summary      Delete a keyword watchlist with its matches.
+// @Tags         watchlist
+// @Produce      json
+// @Param        id   path       int  true  "watchlist id"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      404  {object}  ErrorResponse
+// @Router       /watchlists/{id} [delete]
+func (h Handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+	id, err := pathId(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeError(w, prefix, err)
+		return
+	}
+	response_types.OkJsonBody(w, struct {
+	}{})
+}
+
+// Get godoc
+// @Summary      Get a keyword watchlist.
+// @Tags         watchlist
+// @Produce      json
+// @Param        id   path       int  true  "watchlist id"
+// @Success      200  {object}  WatchlistResponseBody
+// @Failure      404  {object}  ErrorResponse
+// @Router       /watchlists/{id} [get]
+func (h Handlers) Get(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+	id, err := pathId(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+
+	watchlist, err := h.service.Get(id)
+	if err != nil {
+		writeError(w, prefix, err)
+		return
+	}
+	response_types.OkJsonBody(w, toWatchlist(watchlist))
+}
+
+// List godoc
+// @Summary      List keyword watchlists.
+// @Tags         watchlist
+// @Produce      json
+// @Success      200  {object}  ListResponseBody
+// @Failure      500  {object}  ErrorResponse
+// @Router       /watchlists [get]
+func (h Handlers) List(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	watchlists, err := h.service.GetWatchlists()
+	if err != nil {
+		log.Printf("%s error=%v\n", prefix, err)
+		response_types.ErrorNoBody(w, http.StatusInternalServerError, err)
+		return
+	}
+	data := ListResponseBodyData{Watchlists: []Watchlist{}}
+	for _, wl := range watchlists {
+		data.Watchlists = append(data.Watchlists, toWatchlist(wl))
+	}
+	response_types.OkJsonBody(w, data)
+}
+
+// Series godoc
+// @Summary      Mentions of a watchlist's keywords over time.
+// @Description  Per keyword and bucket: distinct posts whose title matched, how many of them were in the top n, and their best rank.
+// @Description  Without rank_order_type and rank_order_created_within_past, matches in every ranking context count.
+// @Description  CSV has one row per bucket and keyword.
+// @Tags         watchlist
+// @Param        id   								path       int     true  "watchlist id"
+// @Param        granularity   						query      string  true  "1=Minute,2=QuarterHour,3=Hour,4=Daily"
+// @Param        from_time   						query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        to_time   							query      string  true  "2006-01-02T15:04:05.000Z"
+// @Param        rank_order_type   					query      string  false "[top,best,hot,new]"
+// @Param        rank_order_created_within_past   	query      string  false "[hour,day,month,year]"
+// @Param        top_n   							query      int     false "rank counted as in the top, default 10"
+// @Accept       json, text/csv
+// @Produce      json, text/csv
+// @Success      200  {object}  SeriesResponseBody
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /watchlists/{id}/series [get]
+func (h Handlers) Series(w http.ResponseWriter, r *http.Request) {
+	prefix := httplog.SPrintHttpRequestPrefix(r)
+
+	contentType, err := response_types.Negotiate(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, err)
+		return
+	}
+	from, err := time.Parse(timeLayout, r.URL.Query().Get("from_time"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("from_time: %w", err))
+		return
+	}
+	to, err := time.Parse(timeLayout, r.URL.Query().Get("to_time"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("to_time: %w", err))
+		return
+	}
+	granularity, err := strconv.Atoi(r.URL.Query().Get("granularity"))
+	if err != nil {
+		response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("granularity: %w", err))
+		return
+	}
+	topN := 10
+	if _topN := r.URL.Query().Get("top_n"); _topN != "" {
+		topN, err = strconv.Atoi(_topN)
+		if err != nil {
+			response_types.ErrorNoBody(w, http.StatusBadRequest, fmt.Errorf("top_n: %w", err))
+			return
+		}
+	}
+	orderType := r.URL.Query().Get("rank_order_type")
+	past := r.URL.Query().Get("rank_order_created_within_past")
+
+	series, err := h.service.Series(id, from, to, statisticsrepo.Granularity(granularity), orderType, past, int32(topN))
+	if err != nil {
+		writeError(w, prefix, err)
+		return
+	}
+
+	switch contentType {
+	case "application/json":
+		response_types.OkJsonBody(w, toSeriesJSON(series, int32(topN)))
+	case "text/csv":
+		layout := "2006-01-02_15-04-05"
+		name := fmt.Sprintf(`watchlist_%d_FROM_%s_TO_%s`, id, from.Format(layout), to.Format(layout))
+		response_types.Csv(w, name, toSeriesCSV(series))
+	}
+}
+
+func toSeriesCSV(s watchlistservice.Series) [][]string {
+	rows := [][]string{{"bucket", "keyword_id", "keyword", "mentions", "in_top_n", "best_rank"}}
+	for i, bucket := range s.Buckets {
+		for _, ks := range s.Keywords {
+			bestRank := ""
+			if ks.BestRank[i] != nil {
+				bestRank = strconv.FormatInt(int64(*ks.BestRank[i]), 10)
+			}
+			rows = append(rows, []string{
+				bucket.UTC().String(),
+				strconv.FormatInt(ks.Keyword.Id, 10),
+				ks.Keyword.Keyword,
+				strconv.FormatInt(ks.Mentions[i], 10),
+				strconv.FormatInt(ks.InTopN[i], 10),
+				bestRank,
+			})
+		}
+	}
+	return rows
+}
+
+func toSeriesJSON(s watchlistservice.Series, topN int32) SeriesResponseBodyData {
+	data := SeriesResponseBodyData{
+		Watchlist: toWatchlist(s.Watchlist),
+		TopN:      topN,
+		Buckets:   s.Buckets,
+		Keywords:  []KeywordSeries{},
+	}
+	for _, ks := range s.Keywords {
+		data.Keywords = append(data.Keywords, KeywordSeries{
+			Keyword:  toKeyword(ks.Keyword),
+			Mentions: ks.Mentions,
+			InTopN:   ks.InTopN,
+			BestRank: ks.BestRank,
+		})
+	}
+	return data
+}
+
+func toKeyword(k watchlistrepo.Keyword) Keyword {
+	return Keyword{
+		Id:            k.Id,
+		Keyword:       k.Keyword,
+		IsRegex:       k.IsRegex,
+		CaseSensitive: k.CaseSensitive,
+	}
+}
+
+func toWatchlist(wl watchlistrepo.Watchlist) Watchlist {
+	out := Watchlist{
+		Id:         wl.Id,
+		Name:       wl.Name,
+		Subreddits: wl.Subreddits,
+		Keywords:   []Keyword{},
+		CreatedAt:  wl.CreatedAt,
+		UpdatedAt:  wl.UpdatedAt,
+	}
+	for _, k := range wl.Keywords {
+		out.Keywords = append(out.Keywords, toKeyword(k))
+	}
+	return out
+}
+
+type Watchlist struct {
+	Id         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Subreddits []string  `json:"subreddits"`
+	Keywords   []Keyword `json:"keywords"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+type WatchlistResponseBody = response_types.Response[Watchlist]
+
+type ListResponseBodyData struct {
+	Watchlists []Watchlist `json:"watchlists"`
+}
+type ListResponseBody = response_types.Response[ListResponseBodyData]
+
+// KeywordSeries counts are aligned with SeriesResponseBodyData.Buckets.
+type KeywordSeries struct {
+	Keyword  Keyword  `json:"keyword"`
+	Mentions []int64  `json:"mentions"`  // distinct posts matched
+	InTopN   []int64  `json:"in_top_n"`  // distinct posts matched at or above the top n rank
+	BestRank []*int32 `json:"best_rank"` // null without matches
+}
+
+type SeriesResponseBodyData struct {
+	Watchlist Watchlist       `json:"watchlist"`
+	TopN      int32           `json:"top_n"`
+	Buckets   []time.Time     `json:"buckets"`
+	Keywords  []KeywordSeries `json:"keywords"`
+}
+type SeriesResponseBody = response_types.Response[SeriesResponseBodyData]
+
+type ErrorResponse = response_types.Response[struct{}]
@@ -53,6 +53,21 @@ func JsonBody[T any](w http.ResponseWriter, httpStatusCode int, body T) {
 	w.Write(b)
 }
 
+// Negotiate returns the content type of the response, application/json or text/csv, from the Accept header.
+func Negotiate(r *http.Request) (string, error) {
+	contentType := r.Header.Get("Accept")
+	if contentType != "application/json" && contentType != "text/csv" {
+		return "", fmt.Errorf("content type %s not supported", contentType)
+	}
+	return contentType, nil
+}
+
+func Text(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(body))
+}
+
 func Csv(w http.ResponseWriter, filename string, body [][]string) {
 	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
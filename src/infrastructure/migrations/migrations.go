@@ -0,0 +1,302 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sqlFS holds the versioned migrations as <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed sql/*.sql
+var sqlFS embed.FS
+
+// advisoryLockKey serializes migrations when several replicas start at once.
+const advisoryLockKey int64 = 7265_0033
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load parses the embedded migrations, ordered by version.
+func Load() ([]Migration, error) {
+	files, err := fs.Glob(sqlFS, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		base := path.Base(file)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", base)
+		}
+
+		_version, name, ok := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>", base)
+		}
+		version, err := strconv.ParseInt(_version, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", base, err)
+		}
+
+		b, err := sqlFS.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	var migrations []Migration
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return int(a.Version - b.Version)
+	})
+	return migrations, nil
+}
+
+type Migrator struct {
+	conn       *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(conn *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		conn:       conn,
+		migrations: migrations,
+	}, nil
+}
+
+// Latest is the version this binary expects the schema to be at.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `create table if not exists schema_migrations
+(
+    version    bigint primary key,
+    name       text        not null,
+    applied_at timestamptz not null default now()
+)`)
+	return err
+}
+
+type Applied struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+func (m *Migrator) applied(ctx context.Context) ([]Applied, error) {
+	var exists bool
+	err := m.conn.QueryRow(ctx, "select to_regclass('schema_migrations') is not null").Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := m.conn.Query(ctx, "select version, name, applied_at from schema_migrations order by version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []Applied
+	for rows.Next() {
+		var t Applied
+		rows.Scan(&t.Version, &t.Name, &t.AppliedAt)
+		if err := rows.Err(); err != nil {
+			return []Applied{}, err
+		}
+		applied = append(applied, t)
+	}
+	return applied, nil
+}
+
+// Up applies the pending migrations, each in its own transaction, and returns them.
+func (m *Migrator) Up() ([]Migration, error) {
+	var done []Migration
+	for _, migration := range m.migrations {
+		ok, err := m.step(migration, true)
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+		}
+		if ok {
+			log.Printf("migrate up %d_%s\n", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations and returns them.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		ok, err := m.step(migration, false)
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+		}
+		if ok {
+			log.Printf("migrate down %d_%s\n", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// step applies or reverts one migration unless it already is in that state.
+func (m *Migrator) step(migration Migration, up bool) (bool, error) {
+	ctx := context.Background()
+	tx, err := m.conn.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "select pg_advisory_xact_lock($1)", advisoryLockKey)
+	if err != nil {
+		return false, err
+	}
+	err = m.ensureVersionTable(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+
+	var isApplied bool
+	err = tx.QueryRow(ctx, "select exists(select 1 from schema_migrations where version = $1)", migration.Version).Scan(&isApplied)
+	if err != nil {
+		return false, err
+	}
+	if isApplied == up {
+		return false, nil
+	}
+
+	if up {
+		_, err = tx.Exec(ctx, migration.Up)
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, "insert into schema_migrations(version, name) VALUES ($1,$2)", migration.Version, migration.Name)
+	} else {
+		_, err = tx.Exec(ctx, migration.Down)
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, "delete from schema_migrations where version = $1", migration.Version)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Known     bool // false when the database has a migration this binary does not ship
+}
+
+// Status lists every migration known to the binary or recorded in the database.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	appliedByVersion := make(map[int64]Applied)
+	for _, a := range applied {
+		appliedByVersion[a.Version] = a
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		s := Status{Version: migration.Version, Name: migration.Name, Known: true}
+		if a, ok := appliedByVersion[migration.Version]; ok {
+			s.AppliedAt = &a.AppliedAt
+			delete(appliedByVersion, migration.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, a := range applied {
+		if _, ok := appliedByVersion[a.Version]; ok {
+			statuses = append(statuses, Status{Version: a.Version, Name: a.Name, AppliedAt: &a.AppliedAt})
+		}
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return int(a.Version - b.Version)
+	})
+	return statuses, nil
+}
+
+// Check returns an error unless every migration of the binary, and nothing else, is applied.
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	var pending, unknown []string
+	for _, s := range statuses {
+		name := fmt.Sprintf("%d_%s", s.Version, s.Name)
+		if !s.Known {
+			unknown = append(unknown, name)
+		} else if s.AppliedAt == nil {
+			pending = append(pending, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("incompatible schema, database has migrations this binary does not know: %s", strings.Join(unknown, ", "))
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("incompatible schema, pending migrations: %s. run `migrate up` or set MIGRATE_ON_STARTUP=TRUE", strings.Join(pending, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,2 @@
+drop table if exists post_statistics;
+drop table if exists tasks;
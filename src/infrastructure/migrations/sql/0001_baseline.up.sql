@@ -0,0 +1,33 @@
+-- Tables that predate versioned migrations. "if not exists" lets existing databases adopt this version.
+create table if not exists tasks
+(
+    id                        bigserial primary key,
+    subreddit_name            text   not null,
+    min_item_count            bigint not null,
+    interval                  text   not null,
+    order_by                  text   not null,
+    posts_created_within_past text   not null
+);
+
+create table if not exists post_statistics
+(
+    id                             bigserial primary key,
+    title                          text        not null,
+    perma_link_path                text        not null,
+    data_ks_id                     text        not null,
+    score                          integer,
+    subreddit_id                   text        not null,
+    comment_count                  integer,
+    subreddit_name                 text        not null,
+    polled_time                    timestamptz not null,
+    author_id                      text        not null,
+    author_name                    text        not null,
+    polled_time_rounded_min        timestamptz not null,
+    rank                           integer     not null,
+    rank_order_type                text        not null,
+    rank_order_created_within_past text        not null,
+    post_created_at                timestamptz
+);
+
+create index if not exists post_statistics_context_idx
+    on post_statistics (subreddit_name, rank_order_type, rank_order_created_within_past, polled_time_rounded_min);
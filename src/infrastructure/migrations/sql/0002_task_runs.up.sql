@@ -0,0 +1,14 @@
+-- One row per task per scheduled window. The unique key is what makes a
+-- window run exactly once across replicas.
+create table if not exists task_runs
+(
+    id           bigserial primary key,
+    task_id      bigint      not null references tasks (id) on delete cascade,
+    scheduled_at timestamptz not null,
+    replica_id   text        not null,
+    status       text        not null,
+    started_at   timestamptz not null default now(),
+    finished_at  timestamptz,
+    error        text,
+    unique (task_id, scheduled_at)
+);
@@ -0,0 +1,2 @@
+alter table tasks
+    drop column if exists catch_up_policy;
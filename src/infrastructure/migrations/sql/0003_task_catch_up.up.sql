@@ -0,0 +1,3 @@
+-- What to do on startup with the windows a task missed: skip, once or all.
+alter table tasks
+    add column if not exists catch_up_policy text not null default 'skip';
@@ -0,0 +1,5 @@
+alter table tasks
+    drop column if exists starts_at,
+    drop column if exists ends_at,
+    drop column if exists max_runs,
+    drop column if exists status;
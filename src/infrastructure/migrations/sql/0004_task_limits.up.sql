@@ -0,0 +1,6 @@
+-- Optional lifetime limits. A task reaching ends_at or max_runs is moved to the completed status instead of being deleted.
+alter table tasks
+    add column if not exists starts_at timestamptz,
+    add column if not exists ends_at   timestamptz,
+    add column if not exists max_runs  bigint,
+    add column if not exists status    text not null default 'active';
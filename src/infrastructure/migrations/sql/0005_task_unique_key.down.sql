@@ -0,0 +1 @@
+drop index if exists tasks_active_key_idx;
@@ -0,0 +1,4 @@
+-- At most one active task per effective key. Subreddit names are case insensitive on reddit.
+create unique index if not exists tasks_active_key_idx
+    on tasks (lower(subreddit_name), interval, order_by, posts_created_within_past)
+    where status = 'active';
@@ -0,0 +1,3 @@
+-- Per task scraper tuning, see task.ScraperOptions.
+alter table tasks
+    add column if not exists scraper_options jsonb not null default '{}';
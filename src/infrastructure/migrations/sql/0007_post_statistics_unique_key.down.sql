@@ -0,0 +1 @@
+drop index if exists post_statistics_observation_key_idx;
@@ -0,0 +1,4 @@
+-- One observation per post, poll bucket and ranking context.
+-- Fails while duplicates exist: run `cli statistics dedupe` first.
+create unique index if not exists post_statistics_observation_key_idx
+    on post_statistics (data_ks_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past);
@@ -0,0 +1,2 @@
+drop table if exists post_statistics_daily;
+drop table if exists post_statistics_hourly;
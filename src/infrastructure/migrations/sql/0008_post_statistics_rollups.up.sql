@@ -0,0 +1,65 @@
+-- Per post, per bucket aggregates of post_statistics, maintained by the rollup job.
+
+create table if not exists post_statistics_hourly
+(
+    bucket_start                   timestamptz not null,
+    data_ks_id                     text        not null,
+    rank_order_type                text        not null,
+    rank_order_created_within_past text        not null,
+
+    subreddit_name                 text        not null,
+    subreddit_id                   text        not null,
+    title                          text        not null,
+    perma_link_path                text        not null,
+    author_id                      text        not null,
+    author_name                    text        not null,
+
+    observations                   integer     not null,
+    last_polled_time               timestamptz not null,
+    last_rank                      integer     not null,
+    min_rank                       integer     not null,
+    max_rank                       integer     not null,
+    avg_rank                       double precision not null,
+    last_score                     integer,
+    min_score                      integer,
+    max_score                      integer,
+    avg_score                      double precision,
+    last_comment_count             integer,
+
+    primary key (data_ks_id, bucket_start, rank_order_type, rank_order_created_within_past)
+);
+
+create index if not exists post_statistics_hourly_context_idx
+    on post_statistics_hourly (subreddit_name, rank_order_type, rank_order_created_within_past, bucket_start);
+
+create table if not exists post_statistics_daily
+(
+    bucket_start                   timestamptz not null,
+    data_ks_id                     text        not null,
+    rank_order_type                text        not null,
+    rank_order_created_within_past text        not null,
+
+    subreddit_name                 text        not null,
+    subreddit_id                   text        not null,
+    title                          text        not null,
+    perma_link_path                text        not null,
+    author_id                      text        not null,
+    author_name                    text        not null,
+
+    observations                   integer     not null,
+    last_polled_time               timestamptz not null,
+    last_rank                      integer     not null,
+    min_rank                       integer     not null,
+    max_rank                       integer     not null,
+    avg_rank                       double precision not null,
+    last_score                     integer,
+    min_score                      integer,
+    max_score                      integer,
+    avg_score                      double precision,
+    last_comment_count             integer,
+
+    primary key (data_ks_id, bucket_start, rank_order_type, rank_order_created_within_past)
+);
+
+create index if not exists post_statistics_daily_context_idx
+    on post_statistics_daily (subreddit_name, rank_order_type, rank_order_created_within_past, bucket_start);
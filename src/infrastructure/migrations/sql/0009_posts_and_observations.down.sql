@@ -0,0 +1,61 @@
+alter table post_observations_hourly rename to post_statistics_hourly;
+alter index post_observations_hourly_pkey rename to post_statistics_hourly_pkey;
+alter index post_observations_hourly_context_idx rename to post_statistics_hourly_context_idx;
+
+alter table post_observations_daily rename to post_statistics_daily;
+alter index post_observations_daily_pkey rename to post_statistics_daily_pkey;
+alter index post_observations_daily_context_idx rename to post_statistics_daily_context_idx;
+
+create table post_statistics
+(
+    id                             bigserial primary key,
+    title                          text        not null,
+    perma_link_path                text        not null,
+    data_ks_id                     text        not null,
+    score                          integer,
+    subreddit_id                   text        not null,
+    comment_count                  integer,
+    subreddit_name                 text        not null,
+    polled_time                    timestamptz not null,
+    author_id                      text        not null,
+    author_name                    text        not null,
+    polled_time_rounded_min        timestamptz not null,
+    rank                           integer     not null,
+    rank_order_type                text        not null,
+    rank_order_created_within_past text        not null,
+    post_created_at                timestamptz
+);
+
+create index post_statistics_context_idx
+    on post_statistics (subreddit_name, rank_order_type, rank_order_created_within_past, polled_time_rounded_min);
+
+create unique index post_statistics_observation_key_idx
+    on post_statistics (data_ks_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past);
+
+insert into post_statistics(id, title, perma_link_path, data_ks_id, score, subreddit_id, comment_count, subreddit_name,
+                            polled_time, author_id, author_name, polled_time_rounded_min, rank, rank_order_type,
+                            rank_order_created_within_past, post_created_at)
+select o.id,
+       p.title,
+       p.perma_link_path,
+       p.data_ks_id,
+       o.score,
+       p.subreddit_id,
+       o.comment_count,
+       p.subreddit_name,
+       o.polled_time,
+       p.author_id,
+       p.author_name,
+       o.polled_time_rounded_min,
+       o.rank,
+       o.rank_order_type,
+       o.rank_order_created_within_past,
+       p.post_created_at
+from post_observations o
+         join posts p on p.id = o.post_id;
+
+select setval(pg_get_serial_sequence('post_statistics', 'id'), coalesce(max(id), 0) + 1, false)
+from post_statistics;
+
+drop table post_observations;
+drop table posts;
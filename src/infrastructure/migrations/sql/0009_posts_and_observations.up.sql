@@ -0,0 +1,82 @@
+-- Splits post_statistics into the posts dimension and the post_observations fact table.
+-- Observation ids are kept, so ids handed out before the split still refer to the same observation.
+
+create table posts
+(
+    id              bigserial primary key,
+    data_ks_id      text        not null unique,
+    title           text        not null,
+    perma_link_path text        not null,
+    subreddit_id    text        not null,
+    subreddit_name  text        not null,
+    author_id       text        not null,
+    author_name     text        not null,
+    post_created_at timestamptz,
+    first_seen_at   timestamptz not null,
+    last_seen_at    timestamptz not null
+);
+
+create index posts_subreddit_name_idx
+    on posts (subreddit_name);
+
+create table post_observations
+(
+    id                             bigserial primary key,
+    post_id                        bigint      not null references posts (id) on delete cascade,
+    polled_time                    timestamptz not null,
+    polled_time_rounded_min        timestamptz not null,
+    rank_order_type                text        not null,
+    rank_order_created_within_past text        not null,
+    rank                           integer     not null,
+    score                          integer,
+    comment_count                  integer,
+
+    unique (post_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past)
+);
+
+create index post_observations_context_idx
+    on post_observations (rank_order_type, rank_order_created_within_past, polled_time_rounded_min);
+
+-- metadata is taken from the first observation of each post
+insert into posts(data_ks_id, title, perma_link_path, subreddit_id, subreddit_name, author_id, author_name,
+                  post_created_at, first_seen_at, last_seen_at)
+select distinct on (data_ks_id) data_ks_id,
+                                title,
+                                perma_link_path,
+                                subreddit_id,
+                                subreddit_name,
+                                author_id,
+                                author_name,
+                                post_created_at,
+                                min(polled_time) over (partition by data_ks_id),
+                                max(polled_time) over (partition by data_ks_id)
+from post_statistics
+order by data_ks_id, polled_time;
+
+insert into post_observations(id, post_id, polled_time, polled_time_rounded_min, rank_order_type,
+                              rank_order_created_within_past, rank, score, comment_count)
+select s.id,
+       p.id,
+       s.polled_time,
+       s.polled_time_rounded_min,
+       s.rank_order_type,
+       s.rank_order_created_within_past,
+       s.rank,
+       s.score,
+       s.comment_count
+from post_statistics s
+         join posts p on p.data_ks_id = s.data_ks_id;
+
+select setval(pg_get_serial_sequence('post_observations', 'id'), coalesce(max(id), 0) + 1, false)
+from post_observations;
+
+drop table post_statistics;
+
+-- the rollups of post_statistics now aggregate post_observations
+alter table post_statistics_hourly rename to post_observations_hourly;
+alter index post_statistics_hourly_pkey rename to post_observations_hourly_pkey;
+alter index post_statistics_hourly_context_idx rename to post_observations_hourly_context_idx;
+
+alter table post_statistics_daily rename to post_observations_daily;
+alter index post_statistics_daily_pkey rename to post_observations_daily_pkey;
+alter index post_statistics_daily_context_idx rename to post_observations_daily_context_idx;
@@ -0,0 +1,33 @@
+-- Partitions already moved to the archive schema are left there.
+
+drop table retention_actions;
+drop table archive.post_observations_daily;
+drop table archive.post_observations_hourly;
+
+create table post_observations_unpartitioned
+(
+    id                             bigint      not null default nextval('post_observations_id_seq') primary key,
+    post_id                        bigint      not null references posts (id) on delete cascade,
+    polled_time                    timestamptz not null,
+    polled_time_rounded_min        timestamptz not null,
+    rank_order_type                text        not null,
+    rank_order_created_within_past text        not null,
+    rank                           integer     not null,
+    score                          integer,
+    comment_count                  integer,
+
+    unique (post_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past)
+);
+
+insert into post_observations_unpartitioned
+select *
+from post_observations;
+
+alter sequence post_observations_id_seq owned by post_observations_unpartitioned.id;
+drop table post_observations;
+
+alter table post_observations_unpartitioned rename to post_observations;
+alter index post_observations_unpartitioned_pkey rename to post_observations_pkey;
+
+create index post_observations_context_idx
+    on post_observations (rank_order_type, rank_order_created_within_past, polled_time_rounded_min);
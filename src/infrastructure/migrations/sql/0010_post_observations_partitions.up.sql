@@ -0,0 +1,68 @@
+-- Range partitions post_observations by month of the poll bucket, see repositories/retention.
+-- Partitions are named post_observations_y<yyyy>m<mm> and cover [first of month, first of next month) in UTC.
+
+alter table post_observations rename to post_observations_unpartitioned;
+alter index post_observations_pkey rename to post_observations_unpartitioned_pkey;
+drop index post_observations_context_idx;
+
+create table post_observations
+(
+    id                             bigint      not null default nextval('post_observations_id_seq'),
+    post_id                        bigint      not null references posts (id) on delete cascade,
+    polled_time                    timestamptz not null,
+    polled_time_rounded_min        timestamptz not null,
+    rank_order_type                text        not null,
+    rank_order_created_within_past text        not null,
+    rank                           integer     not null,
+    score                          integer,
+    comment_count                  integer,
+
+    primary key (id, polled_time_rounded_min),
+    constraint post_observations_observation_key
+        unique (post_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past)
+) partition by range (polled_time_rounded_min);
+
+create index post_observations_context_idx
+    on post_observations (rank_order_type, rank_order_created_within_past, polled_time_rounded_min);
+
+-- partitions for the existing data up to three months ahead, the retention job keeps adding them
+do
+$$
+    declare
+        m timestamp;
+    begin
+        for m in select generate_series(
+                                date_trunc('month', coalesce(min(polled_time_rounded_min), now()) at time zone 'UTC'),
+                                date_trunc('month', now() at time zone 'UTC') + interval '3 months',
+                                interval '1 month')
+                 from post_observations_unpartitioned
+            loop
+                execute format('create table %I partition of post_observations for values from (%L) to (%L)',
+                               'post_observations_' || to_char(m, '"y"YYYY"m"MM'),
+                               m::text || '+00',
+                               (m + interval '1 month')::text || '+00');
+            end loop;
+    end
+$$;
+
+insert into post_observations
+select *
+from post_observations_unpartitioned;
+
+alter sequence post_observations_id_seq owned by post_observations.id;
+drop table post_observations_unpartitioned;
+
+-- where the retention job moves expired data in archive mode
+create schema if not exists archive;
+create table archive.post_observations_hourly (like post_observations_hourly);
+create table archive.post_observations_daily (like post_observations_daily);
+
+create table retention_actions
+(
+    id         bigserial primary key,
+    ran_at     timestamptz not null default now(),
+    replica_id text        not null,
+    action     text        not null,
+    target     text        not null,
+    rows       bigint
+);
@@ -0,0 +1,23 @@
+-- Posts climbing unusually fast, see service/trending.
+create table trending_events
+(
+    id                             bigserial primary key,
+    detected_at                    timestamptz      not null default now(),
+    post_id                        bigint           not null references posts (id) on delete cascade,
+    subreddit_name                 text             not null,
+    rank_order_type                text             not null,
+    rank_order_created_within_past text             not null,
+    polled_time_rounded_min        timestamptz      not null,
+    rank                           integer          not null,
+    score                          integer,
+    score_velocity                 double precision,
+    rank_velocity                  double precision,
+    score_z                        double precision,
+    rank_z                         double precision,
+    threshold                      double precision not null,
+
+    unique (post_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past)
+);
+
+create index trending_events_subreddit_idx
+    on trending_events (lower(subreddit_name), polled_time_rounded_min);
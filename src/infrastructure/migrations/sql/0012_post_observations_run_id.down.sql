@@ -0,0 +1,2 @@
+alter table post_observations
+    drop column if exists run_id;
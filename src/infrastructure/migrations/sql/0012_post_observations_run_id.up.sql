@@ -0,0 +1,4 @@
+-- The task run that polled the observation, null for observations stored before this migration or outside a run.
+-- Not a foreign key: runs are deleted with their task while the observations are kept.
+alter table post_observations
+    add column if not exists run_id bigint;
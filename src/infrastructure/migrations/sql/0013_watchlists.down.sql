@@ -0,0 +1,3 @@
+drop table if exists watchlist_matches;
+drop table if exists watchlist_keywords;
+drop table if exists watchlists;
@@ -0,0 +1,39 @@
+-- Keywords tracked in scraped titles, see service/watchlist.
+create table watchlists
+(
+    id         bigserial primary key,
+    name       text        not null unique,
+    subreddits text[]      not null default '{}', -- lower case, empty for every subreddit
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+create table watchlist_keywords
+(
+    id             bigserial primary key,
+    watchlist_id   bigint  not null references watchlists (id) on delete cascade,
+    keyword        text    not null,
+    is_regex       boolean not null default false,
+    case_sensitive boolean not null default false,
+
+    unique (watchlist_id, keyword, is_regex, case_sensitive)
+);
+
+-- One row per keyword matching the title of an observed post, per poll bucket and ranking context.
+create table watchlist_matches
+(
+    id                             bigserial primary key,
+    keyword_id                     bigint      not null references watchlist_keywords (id) on delete cascade,
+    post_id                        bigint      not null references posts (id) on delete cascade,
+    subreddit_name                 text        not null,
+    rank_order_type                text        not null,
+    rank_order_created_within_past text        not null,
+    polled_time_rounded_min        timestamptz not null,
+    rank                           integer     not null,
+    score                          integer,
+
+    unique (keyword_id, post_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past)
+);
+
+create index watchlist_matches_keyword_time_idx
+    on watchlist_matches (keyword_id, polled_time_rounded_min);
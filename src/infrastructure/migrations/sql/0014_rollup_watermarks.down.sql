@@ -0,0 +1,6 @@
+drop table if exists rollup_watermarks;
+
+drop index if exists post_observations_stored_at_idx;
+
+alter table post_observations
+    drop column if exists stored_at;
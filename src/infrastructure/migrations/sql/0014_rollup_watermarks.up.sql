@@ -0,0 +1,14 @@
+-- When an observation was last written, so the rollup job only recomputes the buckets that changed since its last run.
+-- Existing observations get the time of the migration.
+alter table post_observations
+    add column if not exists stored_at timestamptz not null default now();
+
+create index if not exists post_observations_stored_at_idx
+    on post_observations (stored_at);
+
+-- How far the rollup job has got, per rollup table. A table without a row is rolled up from the first observation.
+create table if not exists rollup_watermarks
+(
+    table_name   text primary key,
+    rolled_up_to timestamptz not null
+);
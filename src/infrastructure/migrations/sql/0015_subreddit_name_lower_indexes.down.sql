@@ -0,0 +1,3 @@
+drop index if exists post_observations_daily_context_lower_idx;
+drop index if exists post_observations_hourly_context_lower_idx;
+drop index if exists posts_subreddit_name_lower_idx;
@@ -0,0 +1,10 @@
+-- Subreddits are matched case insensitively, see repositories/statistics and repositories/analytics.
+
+create index if not exists posts_subreddit_name_lower_idx
+    on posts (lower(subreddit_name));
+
+create index if not exists post_observations_hourly_context_lower_idx
+    on post_observations_hourly (lower(subreddit_name), rank_order_type, rank_order_created_within_past, bucket_start);
+
+create index if not exists post_observations_daily_context_lower_idx
+    on post_observations_daily (lower(subreddit_name), rank_order_type, rank_order_created_within_past, bucket_start);
@@ -2,8 +2,11 @@ package reddit_miner
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -70,7 +73,64 @@ const (
 	OrderByAlgoNew  OrderByAlgo = "new"
 )
 
+func newBrowserContext(debugLogEnabled bool) (context.Context, context.CancelFunc) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36"),
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+	)
+
+	// 2. Create an ExecAllocator with custom options
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+
+	var ctxOpts []chromedp.ContextOption
+	if debugLogEnabled {
+		ctxOpts = append(ctxOpts, chromedp.WithDebugf(log.Printf))
+	}
+	ctx, cancelCtx := chromedp.NewContext(
+		allocCtx, ctxOpts...,
+	)
+	return ctx, func() {
+		cancelCtx()
+		cancelAlloc()
+	}
+}
+
+type ScrollStrategy string
+
+const (
+	ScrollStrategyNone       ScrollStrategy = "none"        // read the first page as loaded
+	ScrollStrategyOnce       ScrollStrategy = "once"        // scroll to the bottom once
+	ScrollStrategyUntilCount ScrollStrategy = "until_count" // scroll until MinItems are loaded or MaxScrolls is reached
+)
+
+// Options tune a single scrape.
+type Options struct {
+	ScrollStrategy ScrollStrategy
+	ScrollWait     time.Duration // wait after each scroll for the listing to load
+	MaxScrolls     int           // bound for ScrollStrategyUntilCount
+	MinItems       int           // target for ScrollStrategyUntilCount
+	MaxItems       int           // posts beyond this rank are dropped, 0 keeps all
+
+	DebugLogEnabled bool
+	SnapshotDir     string // when set, a screenshot and the html of the listing are saved here
+	Retries         int    // extra attempts when the page fails to load or yields no posts
+}
+
+func DefaultOptions() Options {
+	return Options{
+		ScrollStrategy: ScrollStrategyOnce,
+		ScrollWait:     10 * time.Second,
+		MaxScrolls:     1,
+	}
+}
+
 func SubRedditPosts(subReddit string, createdWithinPast CreatedWithinPast, orderBy OrderByAlgo, debugLogEnabled bool) <-chan Post {
+	opts := DefaultOptions()
+	opts.DebugLogEnabled = debugLogEnabled
+	return SubRedditPostsWithOptions(subReddit, createdWithinPast, orderBy, opts)
+}
+
+func SubRedditPostsWithOptions(subReddit string, createdWithinPast CreatedWithinPast, orderBy OrderByAlgo, opts Options) <-chan Post {
 	ch := make(chan Post)
 	go func() {
 		defer close(ch)
@@ -88,61 +148,23 @@ func SubRedditPosts(subReddit string, createdWithinPast CreatedWithinPast, order
 			return
 		}
 
-		opts := append(chromedp.DefaultExecAllocatorOptions[:],
-			chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36"),
-			chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		)
-
-		// 2. Create an ExecAllocator with custom options
-		allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-		defer cancel()
-
-		var ctxOpts []chromedp.ContextOption
-		if debugLogEnabled {
-			ctxOpts = append(ctxOpts, chromedp.WithDebugf(log.Printf))
-		}
-		ctx, cancel := chromedp.NewContext(
-			allocCtx, ctxOpts...,
-		)
-		defer cancel()
-
 		url := fmt.Sprintf("https://www.reddit.com/r/%s/%s?t=%s", subReddit, orderBy, createdWithinPast)
 		log.Printf("SubRedditPosts() URL: %s\n", url)
 
 		var posts []PostDom
-		err := chromedp.Run(ctx,
-			chromedp.Navigate(url),
-			chromedp.ActionFunc(func(ctx context.Context) error {
-				_, exp, err := runtime.Evaluate(`window.scrollTo(0,document.body.scrollHeight);`).Do(ctx)
-				time.Sleep(10 * time.Second)
-				if err != nil {
-					log.Println(err)
-					return err
-				}
-				if exp != nil {
-					log.Println(exp)
-					return exp
-				}
-				return nil
-			}),
-			chromedp.Evaluate(`Array.from(document.querySelectorAll("[data-ks-item]")).map((el, index) => {
-		    const data_ks_id = el.querySelector("a").getAttribute('data-ks-id');
-		    const perma_link_path = el.getAttribute('permalink');
-		    const score = el.getAttribute('score');
-		    const title = el.getAttribute('post-title');
-		    const comment_count = el.getAttribute('comment-count');
-		    const subreddit_id = el.getAttribute('subreddit-id');
-		    const subreddit_prefix_name = el.getAttribute('subreddit-prefixed-name');
-		    const created_timestamp = el.getAttribute('created-timestamp');
-		    const author_id = el.getAttribute('author-id');
-		    const author = el.getAttribute('author');
-		
-		   return { index, subreddit_id, subreddit_prefix_name, perma_link_path,title,comment_count, data_ks_id, score, created_timestamp, author_id, author }
-		})`, &posts),
-		)
-		if err != nil {
-			log.Println(err)
+		for attempt := 0; attempt <= opts.Retries; attempt++ {
+			var err error
+			posts, err = scrapeListing(url, fmt.Sprintf("%s_%s_%s", subReddit, orderBy, createdWithinPast), opts)
+			if err == nil && len(posts) > 0 {
+				break
+			}
+			log.Printf("SubRedditPosts() URL: %s attempt %d/%d posts %d error %v\n", url, attempt+1, opts.Retries+1, len(posts), err)
 		}
+
+		if opts.MaxItems > 0 && len(posts) > opts.MaxItems {
+			posts = posts[:opts.MaxItems]
+		}
+
 		for _, p := range posts {
 			var commentCount *int32
 			_commentCount, err := strconv.Atoi(p.CommentCount)
@@ -178,3 +200,157 @@ func SubRedditPosts(subReddit string, createdWithinPast CreatedWithinPast, order
 	}()
 	return ch
 }
+
+const extractPostsJs = `Array.from(document.querySelectorAll("[data-ks-item]")).map((el, index) => {
+		    const data_ks_id = el.querySelector("a").getAttribute('data-ks-id');
+		    const perma_link_path = el.getAttribute('permalink');
+		    const score = el.getAttribute('score');
+		    const title = el.getAttribute('post-title');
+		    const comment_count = el.getAttribute('comment-count');
+		    const subreddit_id = el.getAttribute('subreddit-id');
+		    const subreddit_prefix_name = el.getAttribute('subreddit-prefixed-name');
+		    const created_timestamp = el.getAttribute('created-timestamp');
+		    const author_id = el.getAttribute('author-id');
+		    const author = el.getAttribute('author');
+		
+		   return { index, subreddit_id, subreddit_prefix_name, perma_link_path,title,comment_count, data_ks_id, score, created_timestamp, author_id, author }
+		})`
+
+func scrapeListing(url string, snapshotName string, opts Options) ([]PostDom, error) {
+	ctx, cancel := newBrowserContext(opts.DebugLogEnabled)
+	defer cancel()
+
+	scrolls := 0
+	switch opts.ScrollStrategy {
+	case ScrollStrategyNone:
+	case ScrollStrategyUntilCount:
+		scrolls = opts.MaxScrolls
+	default:
+		scrolls = 1
+	}
+
+	var posts []PostDom
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(url),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			for i := 0; i < scrolls; i++ {
+				if opts.ScrollStrategy == ScrollStrategyUntilCount {
+					var count int
+					err := chromedp.Evaluate(`document.querySelectorAll("[data-ks-item]").length`, &count).Do(ctx)
+					if err != nil {
+						return err
+					}
+					if count >= opts.MinItems {
+						return nil
+					}
+				}
+
+				_, exp, err := runtime.Evaluate(`window.scrollTo(0,document.body.scrollHeight);`).Do(ctx)
+				time.Sleep(opts.ScrollWait)
+				if err != nil {
+					log.Println(err)
+					return err
+				}
+				if exp != nil {
+					log.Println(exp)
+					return exp
+				}
+			}
+			return nil
+		}),
+		chromedp.Evaluate(extractPostsJs, &posts),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.SnapshotDir != "" {
+		err := snapshot(ctx, opts.SnapshotDir, snapshotName)
+		if err != nil {
+			log.Printf("SubRedditPosts() URL: %s snapshot error %v\n", url, err)
+		}
+	}
+	return posts, nil
+}
+
+// snapshot saves a screenshot and the html of the current page, for debugging extraction.
+func snapshot(ctx context.Context, dir string, name string) error {
+	var png []byte
+	var html string
+	err := chromedp.Run(ctx,
+		chromedp.FullScreenshot(&png, 80),
+		chromedp.OuterHTML("html", &html),
+	)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name+"_"+time.Now().UTC().Format("2006-01-02T15-04-05.000"))
+	log.Printf("snapshot() %s\n", path)
+	err = os.WriteFile(path+".jpg", png, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".html", []byte(html), 0o644)
+}
+
+// About is the subset of /r/<name>/about.json used to tell whether a subreddit can be scraped.
+// Private and banned subreddits answer with a reason and an error code instead of data.
+type About struct {
+	Kind string `json:"kind"`
+	Data struct {
+		DisplayName   string `json:"display_name"`
+		SubredditType string `json:"subreddit_type"`
+	} `json:"data"`
+	Reason string `json:"reason"`
+	Error  int    `json:"error"`
+}
+
+func SubRedditAbout(subReddit string) (About, error) {
+	ctx, cancel := newBrowserContext(false)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelTimeout()
+
+	url := fmt.Sprintf("https://www.reddit.com/r/%s/about.json", subReddit)
+	log.Printf("SubRedditAbout() URL: %s\n", url)
+
+	var body string
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(url),
+		chromedp.Evaluate(`document.body.innerText`, &body),
+	)
+	if err != nil {
+		return About{}, err
+	}
+
+	var about About
+	err = json.Unmarshal([]byte(body), &about)
+	if err != nil {
+		return About{}, fmt.Errorf("unexpected about response for %s: %w", subReddit, err)
+	}
+	return about, nil
+}
+
+// ValidateSubReddit returns an error when the subreddit does not exist, or is private or banned.
+func ValidateSubReddit(subReddit string) error {
+	about, err := SubRedditAbout(subReddit)
+	if err != nil {
+		return err
+	}
+
+	if about.Reason != "" {
+		return fmt.Errorf("subreddit %s is not accessible: %s", subReddit, about.Reason)
+	}
+	if about.Error != 0 || about.Kind != "t5" {
+		return fmt.Errorf("subreddit %s does not exist", subReddit)
+	}
+	if about.Data.SubredditType == "private" {
+		return fmt.Errorf("subreddit %s is private", subReddit)
+	}
+	return nil
+}
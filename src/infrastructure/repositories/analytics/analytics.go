@@ -0,0 +1,16 @@
+package analytics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repo runs the analytical queries over posts and post_observations.
+type Repo struct {
+	conn *pgxpool.Pool
+}
+
+func New(conn *pgxpool.Pool) *Repo {
+	return &Repo{
+		conn: conn,
+	}
+}
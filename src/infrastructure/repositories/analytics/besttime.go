@@ -0,0 +1,44 @@
+package analytics
+
+import (
+	"context"
+	"time"
+)
+
+// PostOutcome is how far a post got in a ranking context over all its observations.
+type PostOutcome struct {
+	PostCreatedAt time.Time
+	BestRank      int32
+	PeakScore     *int32
+}
+
+// PostOutcomes lists the outcome of every post of the subreddit created in [from, to) and observed in the ranking context.
+func (r *Repo) PostOutcomes(subredditName string, orderType string, past string, from time.Time, to time.Time) ([]PostOutcome, error) {
+	rows, err := r.conn.Query(context.Background(), `select p.post_created_at,
+		min(o.rank) as best_rank,
+		max(o.score) as peak_score
+		from posts p
+		join post_observations o on o.post_id = p.id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and $4 <= p.post_created_at
+		and p.post_created_at < $5
+		group by p.id
+;`, subredditName, orderType, past, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outcomes []PostOutcome
+	for rows.Next() {
+		var t PostOutcome
+		rows.Scan(&t.PostCreatedAt, &t.BestRank, &t.PeakScore)
+		if err := rows.Err(); err != nil {
+			return []PostOutcome{}, err
+		}
+		outcomes = append(outcomes, t)
+	}
+	return outcomes, nil
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// bucketExpr puts o.polled_time_rounded_min into buckets of $2 seconds, aligned on UTC midnight.
+const bucketExpr = `date_bin(make_interval(secs => $2), o.polled_time_rounded_min, timestamptz '2001-01-01 00:00:00+00')`
+
+// TopNBucket is the top n of a subreddit over one bucket.
+type TopNBucket struct {
+	SubredditName      string // lower case
+	Bucket             time.Time
+	MeanScore          *float64
+	MedianCommentCount *float64
+	PostIds            []int64 // distinct posts observed in the top n
+}
+
+// TopNBuckets aggregates the observations at or above the top n rank, per subreddit and bucket of the step.
+func (r *Repo) TopNBuckets(subredditNames []string, step time.Duration, orderType string, past string, from time.Time, to time.Time, topN int32) ([]TopNBucket, error) {
+	rows, err := r.conn.Query(context.Background(), fmt.Sprintf(`select lower(p.subreddit_name),
+		%s as bucket,
+		avg(o.score)::double precision,
+		percentile_cont(0.5) within group (order by o.comment_count),
+		array_agg(distinct o.post_id)
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = any ($1)
+		and o.rank_order_type = $3
+		and o.rank_order_created_within_past = $4
+		and $5 <= o.polled_time_rounded_min
+		and o.polled_time_rounded_min < $6
+		and o.rank <= $7
+		group by 1, 2
+		order by 1, 2
+;`, bucketExpr), subredditNames, step.Seconds(), orderType, past, from, to, topN)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []TopNBucket
+	for rows.Next() {
+		var t TopNBucket
+		rows.Scan(&t.SubredditName, &t.Bucket, &t.MeanScore, &t.MedianCommentCount, &t.PostIds)
+		if err := rows.Err(); err != nil {
+			return []TopNBucket{}, err
+		}
+		buckets = append(buckets, t)
+	}
+	return buckets, nil
+}
+
+// PeakAgeBucket is the average age of the posts of a subreddit that peaked in one bucket.
+type PeakAgeBucket struct {
+	SubredditName  string // lower case
+	Bucket         time.Time
+	AvgHoursAtPeak float64
+}
+
+// PeakAgeBuckets places each post's peak, its first poll at its best rank within the range, into buckets of the step
+// and averages the post ages at peak. Posts without a known creation time are left out.
+func (r *Repo) PeakAgeBuckets(subredditNames []string, step time.Duration, orderType string, past string, from time.Time, to time.Time) ([]PeakAgeBucket, error) {
+	rows, err := r.conn.Query(context.Background(), fmt.Sprintf(`with peaks as (
+		select distinct on (o.post_id) o.post_id,
+		lower(p.subreddit_name) as subreddit_name,
+		o.polled_time_rounded_min,
+		extract(epoch from o.polled_time - p.post_created_at)::double precision / 3600 as hours
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = any ($1)
+		and o.rank_order_type = $3
+		and o.rank_order_created_within_past = $4
+		and $5 <= o.polled_time_rounded_min
+		and o.polled_time_rounded_min < $6
+		and p.post_created_at is not null
+		order by o.post_id, o.rank, o.polled_time_rounded_min
+	)
+	select o.subreddit_name, %s as bucket, avg(o.hours)
+	from peaks o
+	group by 1, 2
+	order by 1, 2
+;`, bucketExpr), subredditNames, step.Seconds(), orderType, past, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []PeakAgeBucket
+	for rows.Next() {
+		var t PeakAgeBucket
+		rows.Scan(&t.SubredditName, &t.Bucket, &t.AvgHoursAtPeak)
+		if err := rows.Err(); err != nil {
+			return []PeakAgeBucket{}, err
+		}
+		buckets = append(buckets, t)
+	}
+	return buckets, nil
+}
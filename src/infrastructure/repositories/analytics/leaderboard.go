@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type LeaderboardMetric string
+
+const (
+	LeaderboardMetricPostsInTopN LeaderboardMetric = "posts_in_top_n"
+	LeaderboardMetricHoursInTopN LeaderboardMetric = "hours_in_top_n"
+	LeaderboardMetricBestRank    LeaderboardMetric = "best_rank"
+	LeaderboardMetricPeakScore   LeaderboardMetric = "peak_score"
+)
+
+// leaderboardOrder ranks authors by the metric, best first.
+var leaderboardOrder = map[LeaderboardMetric]string{
+	LeaderboardMetricPostsInTopN: "posts_in_top_n desc",
+	LeaderboardMetricHoursInTopN: "hours_in_top_n desc",
+	LeaderboardMetricBestRank:    "best_rank asc",
+	LeaderboardMetricPeakScore:   "peak_score desc nulls last",
+}
+
+func (m LeaderboardMetric) Valid() bool {
+	_, ok := leaderboardOrder[m]
+	return ok
+}
+
+type AuthorStanding struct {
+	AuthorId    string
+	AuthorName  string
+	Posts       int64 // distinct posts observed in the context
+	PostsInTopN int64 // distinct posts observed at or above the top n rank
+	HoursInTopN int64 // distinct post hours observed at or above the top n rank
+	BestRank    int32
+	PeakScore   *int32
+}
+
+// Leaderboard ranks the authors of the subreddit by the metric over the observations of the ranking context polled in [from, to).
+func (r *Repo) Leaderboard(subredditName string, orderType string, past string, from time.Time, to time.Time, topN int32, metric LeaderboardMetric, limit int) ([]AuthorStanding, error) {
+	order, ok := leaderboardOrder[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard metric %s", metric)
+	}
+
+	rows, err := r.conn.Query(context.Background(), fmt.Sprintf(`select p.author_id,
+		min(p.author_name) as author_name,
+		count(distinct p.id) as posts,
+		count(distinct p.id) filter (where o.rank <= $6) as posts_in_top_n,
+		count(distinct (p.id, date_trunc('hour', o.polled_time_rounded_min))) filter (where o.rank <= $6) as hours_in_top_n,
+		min(o.rank) as best_rank,
+		max(o.score) as peak_score
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and $4 <= o.polled_time_rounded_min
+		and o.polled_time_rounded_min < $5
+		group by p.author_id
+		order by %s, author_name
+		limit $7
+;`, order), subredditName, orderType, past, from, to, topN, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var standings []AuthorStanding
+	for rows.Next() {
+		var t AuthorStanding
+		rows.Scan(&t.AuthorId, &t.AuthorName, &t.Posts, &t.PostsInTopN, &t.HoursInTopN, &t.BestRank, &t.PeakScore)
+		if err := rows.Err(); err != nil {
+			return []AuthorStanding{}, err
+		}
+		standings = append(standings, t)
+	}
+	return standings, nil
+}
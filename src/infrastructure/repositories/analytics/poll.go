@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoPoll is returned when the subreddit has no poll in the ranking context.
+var ErrNoPoll = errors.New("no poll of the subreddit in the ranking context")
+
+// NearestPoll returns the poll bucket of the subreddit and ranking context closest to the time, the earlier one on a tie.
+func (r *Repo) NearestPoll(subredditName string, orderType string, past string, at time.Time) (time.Time, error) {
+	var bucket time.Time
+	err := r.conn.QueryRow(context.Background(), `select bucket from (
+		(select o.polled_time_rounded_min as bucket
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and o.polled_time_rounded_min <= $4
+		order by o.polled_time_rounded_min desc
+		limit 1)
+		union all
+		(select o.polled_time_rounded_min as bucket
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and o.polled_time_rounded_min > $4
+		order by o.polled_time_rounded_min
+		limit 1)
+	) nearest
+	order by abs(extract(epoch from bucket - $4)), bucket
+	limit 1
+;`, subredditName, orderType, past, at).Scan(&bucket)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, ErrNoPoll
+	}
+	return bucket, err
+}
+
+// RankedPost is a post as ranked at a poll.
+type RankedPost struct {
+	PostId        int64
+	DataKsId      string
+	Title         string
+	PermaLinkPath string
+	AuthorName    string
+	PostCreatedAt *time.Time
+	PolledTime    time.Time
+	RunId         *int64
+	Rank          int32
+	Score         *int32
+	CommentCount  *int32
+}
+
+// GetPoll lists the posts of the subreddit and ranking context at the poll bucket, by rank.
+func (r *Repo) GetPoll(subredditName string, orderType string, past string, bucket time.Time) ([]RankedPost, error) {
+	rows, err := r.conn.Query(context.Background(), `select p.id, p.data_ks_id, p.title, p.perma_link_path, p.author_name, p.post_created_at,
+		o.polled_time, o.run_id, o.rank, o.score, o.comment_count
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and o.polled_time_rounded_min = $4
+		order by o.rank, p.data_ks_id
+;`, subredditName, orderType, past, bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []RankedPost
+	for rows.Next() {
+		var t RankedPost
+		rows.Scan(&t.PostId, &t.DataKsId, &t.Title, &t.PermaLinkPath, &t.AuthorName, &t.PostCreatedAt,
+			&t.PolledTime, &t.RunId, &t.Rank, &t.Score, &t.CommentCount)
+		if err := rows.Err(); err != nil {
+			return []RankedPost{}, err
+		}
+		posts = append(posts, t)
+	}
+	return posts, nil
+}
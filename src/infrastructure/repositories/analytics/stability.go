@@ -0,0 +1,57 @@
+package analytics
+
+import (
+	"context"
+	"time"
+)
+
+// PollRank is the rank of a post at a poll.
+type PollRank struct {
+	Bucket time.Time
+	PostId int64
+	Rank   int32
+}
+
+// CountPolls counts the polls of the subreddit and ranking context in [from, to).
+func (r *Repo) CountPolls(subredditName string, orderType string, past string, from time.Time, to time.Time) (int64, error) {
+	var n int64
+	err := r.conn.QueryRow(context.Background(), `select count(distinct o.polled_time_rounded_min)
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and $4 <= o.polled_time_rounded_min
+		and o.polled_time_rounded_min < $5
+;`, subredditName, orderType, past, from, to).Scan(&n)
+	return n, err
+}
+
+// GetPollRanks lists the ranked posts of every poll of the subreddit and ranking context in [from, to), by poll and rank.
+func (r *Repo) GetPollRanks(subredditName string, orderType string, past string, from time.Time, to time.Time) ([]PollRank, error) {
+	rows, err := r.conn.Query(context.Background(), `select o.polled_time_rounded_min, o.post_id, o.rank
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and $4 <= o.polled_time_rounded_min
+		and o.polled_time_rounded_min < $5
+		order by o.polled_time_rounded_min, o.rank
+;`, subredditName, orderType, past, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranks []PollRank
+	for rows.Next() {
+		var t PollRank
+		rows.Scan(&t.Bucket, &t.PostId, &t.Rank)
+		if err := rows.Err(); err != nil {
+			return []PollRank{}, err
+		}
+		ranks = append(ranks, t)
+	}
+	return ranks, nil
+}
@@ -0,0 +1,52 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BucketTitle is a post observed in one bucket, with its best rank and peak score there.
+type BucketTitle struct {
+	Bucket    time.Time
+	PostId    int64
+	Title     string
+	BestRank  int32
+	PeakScore *int32
+}
+
+// BucketTitles lists the posts of the subreddit observed in the ranking context at or above the top n rank,
+// per bucket of the step, oldest bucket first.
+func (r *Repo) BucketTitles(subredditName string, step time.Duration, orderType string, past string, from time.Time, to time.Time, topN int32) ([]BucketTitle, error) {
+	rows, err := r.conn.Query(context.Background(), fmt.Sprintf(`select %s as bucket,
+		p.id,
+		p.title,
+		min(o.rank),
+		max(o.score)
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $3
+		and o.rank_order_created_within_past = $4
+		and $5 <= o.polled_time_rounded_min
+		and o.polled_time_rounded_min < $6
+		and o.rank <= $7
+		group by 1, 2
+		order by 1, 2
+;`, bucketExpr), subredditName, step.Seconds(), orderType, past, from, to, topN)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var titles []BucketTitle
+	for rows.Next() {
+		var t BucketTitle
+		rows.Scan(&t.Bucket, &t.PostId, &t.Title, &t.BestRank, &t.PeakScore)
+		if err := rows.Err(); err != nil {
+			return []BucketTitle{}, err
+		}
+		titles = append(titles, t)
+	}
+	return titles, nil
+}
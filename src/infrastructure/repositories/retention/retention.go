@@ -0,0 +1,211 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLockKey serializes retention runs across replicas.
+const advisoryLockKey int64 = 7265_0038
+
+const (
+	partitionedTable = "post_observations"
+	partitionLayout  = "y2006m01"
+	archiveSchema    = "archive"
+)
+
+type Repo struct {
+	conn *pgxpool.Pool
+}
+
+func New(conn *pgxpool.Pool) *Repo {
+	return &Repo{
+		conn: conn,
+	}
+}
+
+// Lock is a session level advisory lock held on a dedicated connection.
+type Lock struct {
+	conn *pgxpool.Conn
+}
+
+func (l *Lock) Release() error {
+	defer l.conn.Release()
+	_, err := l.conn.Exec(context.Background(), "select pg_advisory_unlock($1)", advisoryLockKey)
+	return err
+}
+
+// TryLock returns nil without error when another session runs retention.
+func (r *Repo) TryLock() (*Lock, error) {
+	conn, err := r.conn.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	err = conn.QueryRow(context.Background(), "select pg_try_advisory_lock($1)", advisoryLockKey).Scan(&ok)
+	if err != nil || !ok {
+		conn.Release()
+		return nil, err
+	}
+	return &Lock{conn: conn}, nil
+}
+
+// Partition is a monthly partition of post_observations covering [From, To).
+type Partition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// MonthPartition is the partition holding the observations polled in the month of t, in UTC.
+func MonthPartition(t time.Time) Partition {
+	from := time.Date(t.UTC().Year(), t.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	return Partition{
+		Name: partitionedTable + "_" + from.Format(partitionLayout),
+		From: from,
+		To:   from.AddDate(0, 1, 0),
+	}
+}
+
+// GetPartitions lists the monthly partitions attached to post_observations, oldest first.
+func (r *Repo) GetPartitions() ([]Partition, error) {
+	rows, err := r.conn.Query(context.Background(), `select c.relname
+		from pg_inherits i
+		join pg_class c on c.oid = i.inhrelid
+		where i.inhparent = $1::regclass
+		order by c.relname
+;`, partitionedTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []Partition
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		if err := rows.Err(); err != nil {
+			return []Partition{}, err
+		}
+		month, err := time.Parse(partitionLayout, strings.TrimPrefix(name, partitionedTable+"_"))
+		if err != nil {
+			// not one of ours, leave it alone
+			continue
+		}
+		partitions = append(partitions, MonthPartition(month))
+	}
+	return partitions, nil
+}
+
+// CreatePartition creates the partition unless it exists, and reports whether it did.
+func (r *Repo) CreatePartition(p Partition) (bool, error) {
+	var exists bool
+	err := r.conn.QueryRow(context.Background(), "select to_regclass($1) is not null", p.Name).Scan(&exists)
+	if err != nil || exists {
+		return false, err
+	}
+
+	_, err = r.conn.Exec(context.Background(), fmt.Sprintf("create table if not exists %s partition of %s for values from ('%s') to ('%s')",
+		pgx.Identifier{p.Name}.Sanitize(), partitionedTable, p.From.Format(time.RFC3339), p.To.Format(time.RFC3339)))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DetachPartition detaches the partition, then drops it or moves it to the archive schema.
+func (r *Repo) DetachPartition(p Partition, archive bool) error {
+	ctx := context.Background()
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	name := pgx.Identifier{p.Name}.Sanitize()
+	_, err = tx.Exec(ctx, fmt.Sprintf("alter table %s detach partition %s", partitionedTable, name))
+	if err != nil {
+		return err
+	}
+	if archive {
+		_, err = tx.Exec(ctx, fmt.Sprintf("alter table %s set schema %s", name, archiveSchema))
+	} else {
+		_, err = tx.Exec(ctx, fmt.Sprintf("drop table %s", name))
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+const (
+	RollupTableHourly = "post_observations_hourly"
+	RollupTableDaily  = "post_observations_daily"
+)
+
+// DeleteRollupBefore deletes the buckets of the rollup table starting before the time, or moves them to the archive schema.
+func (r *Repo) DeleteRollupBefore(table string, before time.Time, archive bool) (int64, error) {
+	if table != RollupTableHourly && table != RollupTableDaily {
+		return 0, fmt.Errorf("unknown rollup table %s", table)
+	}
+
+	query := fmt.Sprintf("delete from %s where bucket_start < $1", table)
+	if archive {
+		query = fmt.Sprintf("with moved as (%s returning *) insert into %s.%s select * from moved", query, archiveSchema, table)
+	}
+	tag, err := r.conn.Exec(context.Background(), query, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+type ActionType string
+
+const (
+	ActionCreatePartition  ActionType = "create_partition"
+	ActionDropPartition    ActionType = "drop_partition"
+	ActionArchivePartition ActionType = "archive_partition"
+	ActionDeleteRows       ActionType = "delete_rows"
+	ActionArchiveRows      ActionType = "archive_rows"
+)
+
+type Action struct {
+	Id        int64
+	RanAt     time.Time
+	ReplicaId string
+	Action    ActionType
+	Target    string
+	Rows      *int64
+}
+
+func (r *Repo) InsertAction(a Action) error {
+	_, err := r.conn.Exec(context.Background(), "insert into retention_actions(replica_id, action, target, rows) VALUES ($1,$2,$3,$4)", a.ReplicaId, a.Action, a.Target, a.Rows)
+	return err
+}
+
+// GetActions lists the latest actions, newest first.
+func (r *Repo) GetActions(limit int) ([]Action, error) {
+	rows, err := r.conn.Query(context.Background(), "select id, ran_at, replica_id, action, target, rows from retention_actions order by id desc limit $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []Action
+	for rows.Next() {
+		var t Action
+		rows.Scan(&t.Id, &t.RanAt, &t.ReplicaId, &t.Action, &t.Target, &t.Rows)
+		if err := rows.Err(); err != nil {
+			return []Action{}, err
+		}
+		actions = append(actions, t)
+	}
+	return actions, nil
+}
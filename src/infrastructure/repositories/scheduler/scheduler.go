@@ -0,0 +1,196 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLockNamespace is the top 16 bits of a task's advisory lock key, the task id the lower 48.
+const advisoryLockNamespace int64 = 7265
+
+const taskIdBits = 48
+
+// taskLockKey packs the namespace and the task id into the single bigint key of the task's advisory lock.
+func taskLockKey(taskId int64) (int64, error) {
+	if taskId < 0 || taskId >= 1<<taskIdBits {
+		return 0, fmt.Errorf("task id %d outside the advisory lock key space", taskId)
+	}
+	return advisoryLockNamespace<<taskIdBits | taskId, nil
+}
+
+// missedRunsLockKey is the single-key advisory lock of the missed run warnings.
+const missedRunsLockKey int64 = 7265_0027
+
+type Repo struct {
+	conn *pgxpool.Pool
+}
+
+func New(conn *pgxpool.Pool) *Repo {
+	return &Repo{
+		conn: conn,
+	}
+}
+
+// Lock is a session level advisory lock held on a dedicated connection.
+// Postgres releases it when the connection drops, so a crashed replica does not keep it.
+type Lock struct {
+	conn   *pgxpool.Conn
+	unlock string
+	keys   []any
+}
+
+func (l *Lock) Release() error {
+	defer l.conn.Release()
+	_, err := l.conn.Exec(context.Background(), l.unlock, l.keys...)
+	return err
+}
+
+// TryLock returns nil without error when the task is locked by another session.
+func (r *Repo) TryLock(taskId int64) (*Lock, error) {
+	key, err := taskLockKey(taskId)
+	if err != nil {
+		return nil, err
+	}
+	return r.tryLock("select pg_try_advisory_lock($1)", "select pg_advisory_unlock($1)", key)
+}
+
+// TryLockMissedRuns returns nil without error when another session warns about missed runs.
+func (r *Repo) TryLockMissedRuns() (*Lock, error) {
+	return r.tryLock("select pg_try_advisory_lock($1)", "select pg_advisory_unlock($1)", missedRunsLockKey)
+}
+
+func (r *Repo) tryLock(lock string, unlock string, keys ...any) (*Lock, error) {
+	conn, err := r.conn.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	err = conn.QueryRow(context.Background(), lock, keys...).Scan(&ok)
+	if err != nil || !ok {
+		conn.Release()
+		return nil, err
+	}
+	return &Lock{conn: conn, unlock: unlock, keys: keys}, nil
+}
+
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+// ClaimRun records a run of the task for the scheduled window, or reclaims the window's failed run.
+// claimed is false when any replica has already claimed the window and its run has not failed.
+func (r *Repo) ClaimRun(taskId int64, scheduledAt time.Time, replicaId string) (id int64, claimed bool, err error) {
+	row := r.conn.QueryRow(context.Background(), `insert into task_runs(task_id, scheduled_at, replica_id, status) VALUES ($1,$2,$3,$4)
+		on conflict (task_id, scheduled_at) do update
+		set replica_id = excluded.replica_id, status = excluded.status, started_at = now(), finished_at = null, error = null
+		where task_runs.status = $5
+		RETURNING id`, taskId, scheduledAt, replicaId, RunStatusRunning, RunStatusFailed)
+	err = row.Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+func (r *Repo) FinishRun(id int64, status RunStatus, runErr error) error {
+	var errMsg *string
+	if runErr != nil {
+		msg := runErr.Error()
+		errMsg = &msg
+	}
+	_, err := r.conn.Exec(context.Background(), "update task_runs set status = $2, error = $3, finished_at = now() where id = $1", id, status, errMsg)
+	return err
+}
+
+// ExpireRuns marks the runs started before the time and still running as failed, returning how many.
+func (r *Repo) ExpireRuns(startedBefore time.Time) (int64, error) {
+	tag, err := r.conn.Exec(context.Background(), "update task_runs set status = $2, error = $3, finished_at = now() where status = $1 and started_at < $4",
+		RunStatusRunning, RunStatusFailed, "expired: still running after the run timeout", startedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+type Run struct {
+	Id          int64
+	TaskId      int64
+	ScheduledAt time.Time
+	ReplicaId   string
+	Status      RunStatus
+	StartedAt   time.Time
+	FinishedAt  *time.Time
+	Error       *string
+}
+
+func (r *Repo) GetRunsByStatus(status RunStatus) ([]Run, error) {
+	rows, err := r.conn.Query(context.Background(), "select id, task_id, scheduled_at, replica_id, status, started_at, finished_at, error from task_runs where status = $1 order by started_at", status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var t Run
+		rows.Scan(&t.Id, &t.TaskId, &t.ScheduledAt, &t.ReplicaId, &t.Status, &t.StartedAt, &t.FinishedAt, &t.Error)
+		if err := rows.Err(); err != nil {
+			return []Run{}, err
+		}
+		runs = append(runs, t)
+	}
+	return runs, nil
+}
+
+type LockHolder struct {
+	TaskId          int64
+	ApplicationName string
+	Pid             int32
+	BackendStart    time.Time
+}
+
+// GetLockHolders lists the sessions across the cluster holding task locks.
+// Each replica sets its replica id as the application_name of its connections.
+func (r *Repo) GetLockHolders() ([]LockHolder, error) {
+	// a bigint key is reported as its high 32 bits in classid and its low 32 bits in objid
+	rows, err := r.conn.Query(context.Background(), `select ((l.classid::bigint << 32) | l.objid::bigint) & ((1::bigint << $2) - 1) as task_id,
+		a.application_name,
+		a.pid,
+		a.backend_start
+		from pg_locks l
+		join pg_stat_activity a on a.pid = l.pid
+		where l.locktype = 'advisory'
+		and l.granted
+		and l.objsubid = 1
+		and l.classid::bigint >> ($2 - 32) = $1
+		order by task_id
+;`, advisoryLockNamespace, taskIdBits)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holders []LockHolder
+	for rows.Next() {
+		var t LockHolder
+		rows.Scan(&t.TaskId, &t.ApplicationName, &t.Pid, &t.BackendStart)
+		if err := rows.Err(); err != nil {
+			return []LockHolder{}, err
+		}
+		holders = append(holders, t)
+	}
+	return holders, nil
+}
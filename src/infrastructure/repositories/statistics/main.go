@@ -2,9 +2,13 @@ package statistics
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -67,29 +71,331 @@ type PostForm struct {
 	AuthorId      string
 	AuthorName    string
 	PostCreatedAt time.Time
+
+	RunId *int64 // task run that polled the post, nil outside a run
 }
 
-func (r *Repo) insert(post PostForm) error {
-	row := r.conn.QueryRow(context.Background(), "insert into post_statistics(title, perma_link_path, data_ks_id, score, subreddit_id, comment_count, subreddit_name, polled_time, author_id, author_name, polled_time_rounded_min, rank, rank_order_type, rank_order_created_within_past, post_created_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15) RETURNING id",
-		post.Title, post.PermaLinkPath, post.DataKsId, post.Score, post.SubredditId,
-		post.CommentCount, post.SubredditName, post.PolledTime, post.AuthorId,
-		post.AuthorName, post.PolledTimeRoundedMinute,
-		post.Rank, post.RankOrderType, post.RankOrderForCreatedWithinPast, post.PostCreatedAt,
-	)
-	var id int64
-	return row.Scan(&id)
+// ConflictPolicy decides which observation is kept when a post is stored twice for the same poll bucket and ranking context.
+type ConflictPolicy string
+
+const (
+	ConflictPolicyKeepFirst  ConflictPolicy = "keep_first"
+	ConflictPolicyKeepLatest ConflictPolicy = "keep_latest"
+)
+
+var stagingColumns = []string{
+	"title", "perma_link_path", "data_ks_id", "score", "subreddit_id",
+	"comment_count", "subreddit_name", "polled_time", "author_id",
+	"author_name", "polled_time_rounded_min",
+	"rank", "rank_order_type", "rank_order_created_within_past", "post_created_at", "run_id",
 }
 
-func (r *Repo) InsertMany(posts []PostForm) {
+// observationColumns are the columns of post_observations a conflict policy may replace.
+var observationColumns = []string{"polled_time", "rank", "score", "comment_count", "run_id"}
+
+const observationKey = "post_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past"
+
+// InsertMany upserts the posts of one scrape in a single transaction: all rows land or none do.
+// Rows are copied into a staging table, the posts are added to or touched in posts,
+// then the observations are merged on the observation key according to the policy.
+// The metadata of a post is kept as first seen.
+func (r *Repo) InsertMany(posts []PostForm, policy ConflictPolicy) error {
 	log.Printf("InsertMany Posts length: %d\n", len(posts))
+
+	var onConflict string
+	switch policy {
+	case ConflictPolicyKeepFirst:
+		onConflict = "do nothing"
+	case ConflictPolicyKeepLatest:
+		var sets []string
+		for _, column := range observationColumns {
+			sets = append(sets, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+		sets = append(sets, "stored_at = now()")
+		onConflict = "do update set " + strings.Join(sets, ", ")
+	default:
+		return fmt.Errorf("unknown conflict policy %s", policy)
+	}
+
+	ctx := context.Background()
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `create temp table post_statistics_staging
+(
+    title                          text,
+    perma_link_path                text,
+    data_ks_id                     text,
+    score                          integer,
+    subreddit_id                   text,
+    comment_count                  integer,
+    subreddit_name                 text,
+    polled_time                    timestamptz,
+    author_id                      text,
+    author_name                    text,
+    polled_time_rounded_min        timestamptz,
+    rank                           integer,
+    rank_order_type                text,
+    rank_order_created_within_past text,
+    post_created_at                timestamptz,
+    run_id                         bigint
+) on commit drop`)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, 0, len(posts))
 	for _, post := range posts {
-		go func() {
-			err := r.insert(post)
-			if err != nil {
-				log.Printf("InsertMany error %#v post %v\n", err, post)
-			}
-		}()
+		rows = append(rows, []any{
+			post.Title, post.PermaLinkPath, post.DataKsId, post.Score, post.SubredditId,
+			post.CommentCount, post.SubredditName, post.PolledTime, post.AuthorId,
+			post.AuthorName, post.PolledTimeRoundedMinute,
+			post.Rank, post.RankOrderType, post.RankOrderForCreatedWithinPast, post.PostCreatedAt, post.RunId,
+		})
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"post_statistics_staging"}, stagingColumns, pgx.CopyFromRows(rows))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `insert into posts(data_ks_id, title, perma_link_path, subreddit_id, subreddit_name, author_id, author_name,
+		post_created_at, first_seen_at, last_seen_at)
+		select distinct on (data_ks_id) data_ks_id, title, perma_link_path, subreddit_id, subreddit_name, author_id, author_name,
+		post_created_at, polled_time, polled_time
+		from post_statistics_staging
+		order by data_ks_id, rank
+		on conflict (data_ks_id) do update set
+		first_seen_at = least(posts.first_seen_at, excluded.first_seen_at),
+		last_seen_at = greatest(posts.last_seen_at, excluded.last_seen_at)`)
+	if err != nil {
+		return err
+	}
+
+	// a listing can repeat a post while it loads, keep its best rank
+	_, err = tx.Exec(ctx, fmt.Sprintf(`insert into post_observations(post_id, polled_time, polled_time_rounded_min,
+		rank_order_type, rank_order_created_within_past, rank, score, comment_count, run_id)
+		select distinct on (p.id, s.polled_time_rounded_min, s.rank_order_type, s.rank_order_created_within_past)
+		p.id, s.polled_time, s.polled_time_rounded_min,
+		s.rank_order_type, s.rank_order_created_within_past, s.rank, s.score, s.comment_count, s.run_id
+		from post_statistics_staging s
+		join posts p on p.data_ks_id = s.data_ks_id
+		order by p.id, s.polled_time_rounded_min, s.rank_order_type, s.rank_order_created_within_past, s.rank
+		on conflict (%s) %s`, observationKey, onConflict))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Dedupe deletes the observations the policy would not have kept, for data stored before the observation key was unique.
+// It works on post_statistics, so it only applies to databases migrated up to before 0007_post_statistics_unique_key.
+// With dryRun the count is returned and nothing is deleted.
+func (r *Repo) Dedupe(policy ConflictPolicy, dryRun bool) (int64, error) {
+	var keep string
+	switch policy {
+	case ConflictPolicyKeepFirst:
+		keep = "p.id > q.id"
+	case ConflictPolicyKeepLatest:
+		keep = "p.id < q.id"
+	default:
+		return 0, fmt.Errorf("unknown conflict policy %s", policy)
+	}
+
+	ctx := context.Background()
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`delete from post_statistics p
+		using post_statistics q
+		where p.data_ks_id = q.data_ks_id
+		and p.polled_time_rounded_min = q.polled_time_rounded_min
+		and p.rank_order_type = q.rank_order_type
+		and p.rank_order_created_within_past = q.rank_order_created_within_past
+		and %s`, keep))
+	if err != nil {
+		return 0, err
+	}
+	if dryRun {
+		return tag.RowsAffected(), nil
+	}
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
+
+type rollup struct {
+	table string
+	unit  string // date_trunc unit of the bucket, in UTC
+}
+
+// rollups are the granularities served from rollup tables. The others read post_observations.
+var rollups = map[Granularity]rollup{
+	GranularityHour:  {table: "post_observations_hourly", unit: "hour"},
+	GranularityDaily: {table: "post_observations_daily", unit: "day"},
+}
+
+// rawEveryMinutes samples post_observations at the granularity's bucket boundaries.
+var rawEveryMinutes = map[Granularity]int{
+	GranularityMinute:      1,
+	GranularityQuarterHour: 15,
+}
+
+func HasRollup(granularity Granularity) bool {
+	_, ok := rollups[granularity]
+	return ok
+}
+
+// rollupLockKey is the advisory lock key of the rollup job.
+const rollupLockKey int64 = 7265_0035
+
+// Lock is a session level advisory lock held on a dedicated connection.
+type Lock struct {
+	conn *pgxpool.Conn
+}
+
+func (l *Lock) Release() error {
+	defer l.conn.Release()
+	_, err := l.conn.Exec(context.Background(), "select pg_advisory_unlock($1)", rollupLockKey)
+	return err
+}
+
+// TryLockRollup returns nil without error when another session runs the rollup.
+func (r *Repo) TryLockRollup() (*Lock, error) {
+	conn, err := r.conn.Acquire(context.Background())
+	if err != nil {
+		return nil, err
 	}
+
+	var ok bool
+	err = conn.QueryRow(context.Background(), "select pg_try_advisory_lock($1)", rollupLockKey).Scan(&ok)
+	if err != nil || !ok {
+		conn.Release()
+		return nil, err
+	}
+	return &Lock{conn: conn}, nil
+}
+
+// Rollup recomputes the buckets of the granularity from the one containing since onwards.
+func (r *Repo) Rollup(granularity Granularity, since time.Time) (int64, error) {
+	ru, ok := rollups[granularity]
+	if !ok {
+		return 0, fmt.Errorf("granularity %d has no rollup", granularity)
+	}
+
+	where := fmt.Sprintf("o.polled_time_rounded_min >= date_trunc('%s', $1::timestamptz at time zone 'UTC') at time zone 'UTC'", ru.unit)
+	tag, err := r.conn.Exec(context.Background(), rollupQuery(ru, "", where), since)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// rollupOverlap is how far before the watermark observations are looked at again,
+// for scrapes that committed after the previous rollup but were stored before its watermark.
+const rollupOverlap = 5 * time.Minute
+
+// RollupChanged recomputes the buckets of the granularity holding observations stored since its previous call,
+// then advances the granularity's watermark. Without a watermark every bucket is recomputed.
+func (r *Repo) RollupChanged(granularity Granularity) (int64, error) {
+	ru, ok := rollups[granularity]
+	if !ok {
+		return 0, fmt.Errorf("granularity %d has no rollup", granularity)
+	}
+
+	ctx := context.Background()
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var watermark time.Time
+	err = tx.QueryRow(ctx, "select rolled_up_to from rollup_watermarks where table_name = $1", ru.table).Scan(&watermark)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	var rows int64
+	if errors.Is(err, pgx.ErrNoRows) {
+		tag, err := tx.Exec(ctx, rollupQuery(ru, "", "true"))
+		if err != nil {
+			return 0, err
+		}
+		rows = tag.RowsAffected()
+	} else {
+		changed := fmt.Sprintf(`with changed as (select distinct %s as bucket_start
+		from post_observations c
+		where c.stored_at >= $1)
+		`, bucketOf(ru, "c"))
+		where := fmt.Sprintf(`o.polled_time_rounded_min >= (select min(bucket_start) from changed)
+		and %s in (select bucket_start from changed)`, bucketOf(ru, "o"))
+		tag, err := tx.Exec(ctx, rollupQuery(ru, changed, where), watermark.Add(-rollupOverlap))
+		if err != nil {
+			return 0, err
+		}
+		rows = tag.RowsAffected()
+	}
+
+	// now() is when the transaction started, observations stored after it are picked up next time
+	_, err = tx.Exec(ctx, `insert into rollup_watermarks(table_name, rolled_up_to) values ($1, now())
+		on conflict (table_name) do update set rolled_up_to = excluded.rolled_up_to`, ru.table)
+	if err != nil {
+		return 0, err
+	}
+	return rows, tx.Commit(ctx)
+}
+
+// bucketOf is the start of the rollup bucket of the observation aliased as alias.
+func bucketOf(ru rollup, alias string) string {
+	return fmt.Sprintf("date_trunc('%s', %s.polled_time_rounded_min at time zone 'UTC') at time zone 'UTC'", ru.unit, alias)
+}
+
+// rollupQuery upserts the rollup rows of the observations o matching where, preceded by the with clause.
+func rollupQuery(ru rollup, with string, where string) string {
+	last := func(column string) string {
+		return fmt.Sprintf("(array_agg(o.%s order by o.polled_time_rounded_min desc))[1]", column)
+	}
+	return fmt.Sprintf(`%sinsert into %s(bucket_start, data_ks_id, rank_order_type, rank_order_created_within_past,
+		subreddit_name, subreddit_id, title, perma_link_path, author_id, author_name,
+		observations, last_polled_time,
+		last_rank, min_rank, max_rank, avg_rank,
+		last_score, min_score, max_score, avg_score,
+		last_comment_count)
+		select %s, p.data_ks_id, o.rank_order_type, o.rank_order_created_within_past,
+		p.subreddit_name, p.subreddit_id, p.title, p.perma_link_path, p.author_id, p.author_name,
+		count(*), max(o.polled_time),
+		%s, min(o.rank), max(o.rank), avg(o.rank),
+		%s, min(o.score), max(o.score), avg(o.score),
+		%s
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where %s
+		group by 1, p.id, o.rank_order_type, o.rank_order_created_within_past
+		on conflict (data_ks_id, bucket_start, rank_order_type, rank_order_created_within_past) do update set
+		subreddit_name = excluded.subreddit_name,
+		subreddit_id = excluded.subreddit_id,
+		title = excluded.title,
+		perma_link_path = excluded.perma_link_path,
+		author_id = excluded.author_id,
+		author_name = excluded.author_name,
+		observations = excluded.observations,
+		last_polled_time = excluded.last_polled_time,
+		last_rank = excluded.last_rank,
+		min_rank = excluded.min_rank,
+		max_rank = excluded.max_rank,
+		avg_rank = excluded.avg_rank,
+		last_score = excluded.last_score,
+		min_score = excluded.min_score,
+		max_score = excluded.max_score,
+		avg_score = excluded.avg_score,
+		last_comment_count = excluded.last_comment_count
+;`, with, ru.table, bucketOf(ru, "o"),
+		last("rank"), last("score"), last("comment_count"), where)
 }
 
 type Post struct {
@@ -108,10 +414,81 @@ type Post struct {
 	RankOrderType                 OrderByAlgo
 	RankOrderForCreatedWithinPast CreatedWithinPast
 	Id                            int64
+	PostCreatedAt                 *time.Time
+
+	// Aggregates over the bucket, only set when read from a rollup. Rank and Score are then the last observed.
+	MinRank  *int32
+	MaxRank  *int32
+	AvgRank  *float64
+	MinScore *int32
+	MaxScore *int32
+	AvgScore *float64
+}
+
+// Cursor is the last bucket of a page. Pages hold whole buckets, so a bucket is never split across pages.
+type Cursor struct {
+	Bucket time.Time `json:"b"`
+}
+
+// StatsFilter selects the rows of Stats. A nil FromTime or ToTime does not bound the buckets.
+type StatsFilter struct {
+	Name        string
+	OrderType   OrderByAlgo
+	Past        CreatedWithinPast
+	Granularity Granularity
+	FromTime    *time.Time
+	ToTime      *time.Time
+	MinRank     int32
+	MaxRank     int32
 }
 
-func (r *Repo) Stats(name string, orderType OrderByAlgo, fromTime *time.Time, toTime *time.Time, past CreatedWithinPast, granularity Granularity) ([]Post, error) {
-	rows, err := r.conn.Query(context.Background(), `select id,
+// statsQuery is the select list and the filtered from clause of Stats, with the bucket and rank columns to order by.
+type statsQuery struct {
+	columns string
+	from    string
+	bucket  string
+	rank    string
+	args    []any
+}
+
+// statsQuery reads raw observations at bucket boundaries for minute and quarter hour granularities,
+// and the rollup tables for hour and daily granularities.
+// A rollup bucket matches the rank range when its best rank falls in it.
+func (f StatsFilter) statsQuery() (statsQuery, error) {
+	q := statsQuery{args: []any{f.Name, f.OrderType, f.Past, f.FromTime, f.ToTime, f.MinRank, f.MaxRank}}
+	if ru, ok := rollups[f.Granularity]; ok {
+		q.columns = `0::bigint,
+		title,
+		perma_link_path,
+		data_ks_id,
+		last_score,
+		subreddit_id,
+
+		last_comment_count,
+		subreddit_name,
+		last_polled_time,
+		author_id,
+		author_name,
+
+		bucket_start,
+		last_rank,
+		rank_order_type,
+		rank_order_created_within_past,
+
+		min_rank,
+		max_rank,
+		avg_rank,
+		min_score,
+		max_score,
+		avg_score,
+
+		(select post_created_at from posts where posts.data_ks_id = ` + ru.table + `.data_ks_id)`
+		q.from = ru.table + `
+		where true
+		and min_rank between $6 and $7`
+		q.bucket, q.rank = "bucket_start", "last_rank"
+	} else if every, ok := rawEveryMinutes[f.Granularity]; ok {
+		q.columns = `o.id,
 		title,
 		perma_link_path,
 		data_ks_id,
@@ -127,17 +504,183 @@ func (r *Repo) Stats(name string, orderType OrderByAlgo, fromTime *time.Time, to
 		polled_time_rounded_min,
 		rank,
 		rank_order_type,
-		rank_order_created_within_past
-		from post_statistics
+		rank_order_created_within_past,
+
+		null::integer,
+		null::integer,
+		null::double precision,
+		null::integer,
+		null::integer,
+		null::double precision,
+
+		post_created_at`
+		q.from = `post_observations o
+		join posts p on p.id = o.post_id
 		where true
-		and rank <= 20
-		and subreddit_name = $1
+		and rank between $6 and $7
+		and extract(minute from polled_time_rounded_min)::integer % $8 = 0`
+		q.bucket, q.rank = "polled_time_rounded_min", "rank"
+		q.args = append(q.args, every)
+	} else {
+		return statsQuery{}, fmt.Errorf("granularity type not supported. =%d", f.Granularity)
+	}
+	q.from += fmt.Sprintf(`
+		and lower(subreddit_name) = lower($1)
 		and rank_order_type = $2
 		and rank_order_created_within_past = $3
-		and extract(minute from polled_time_rounded_min)::integer % 60 = 0
-		and $4 < polled_time_rounded_min
-		and polled_time_rounded_min < $5  
-;`, name, orderType, past, fromTime, toTime)
+		and ($4::timestamptz is null or $4 < %[1]s)
+		and ($5::timestamptz is null or %[1]s < $5)`, q.bucket)
+	return q, nil
+}
+
+// arg appends the value to the args and returns its placeholder.
+func (q *statsQuery) arg(v any) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// BucketCount is how many rows of the filter fall in a bucket.
+type BucketCount struct {
+	Bucket time.Time
+	Rows   int64
+}
+
+// StatsBuckets counts the rows of the filter per bucket, oldest first.
+func (r *Repo) StatsBuckets(f StatsFilter) ([]BucketCount, error) {
+	q, err := f.statsQuery()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.conn.Query(context.Background(), fmt.Sprintf(`select %[1]s, count(*)
+		from %[2]s
+		group by %[1]s
+		order by %[1]s
+;`, q.bucket, q.from), q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []BucketCount
+	for rows.Next() {
+		var t BucketCount
+		rows.Scan(&t.Bucket, &t.Rows)
+		if err := rows.Err(); err != nil {
+			return []BucketCount{}, err
+		}
+		buckets = append(buckets, t)
+	}
+	return buckets, nil
+}
+
+// Stats returns the rows of the filter in the buckets after after and up to until, both optional,
+// ordered by bucket, rank and post.
+func (r *Repo) Stats(f StatsFilter, after *time.Time, until *time.Time) ([]Post, error) {
+	q, err := f.statsQuery()
+	if err != nil {
+		return nil, err
+	}
+	where := q.from
+	if after != nil {
+		where += fmt.Sprintf(`
+		and %s > %s`, q.bucket, q.arg(*after))
+	}
+	if until != nil {
+		where += fmt.Sprintf(`
+		and %s <= %s`, q.bucket, q.arg(*until))
+	}
+	return r.queryPosts(fmt.Sprintf(`select %s
+		from %s
+		order by %s, %s, data_ks_id
+;`, q.columns, where, q.bucket, q.rank), q.args...)
+}
+
+// StatsObserved counts the buckets of the filter each post was observed in, by data ks id.
+func (r *Repo) StatsObserved(f StatsFilter) (map[string]int64, error) {
+	q, err := f.statsQuery()
+	if err != nil {
+		return nil, err
+	}
+	return r.queryCounts(fmt.Sprintf(`select data_ks_id, count(*)
+		from %s
+		group by data_ks_id
+;`, q.from), q.args...)
+}
+
+// StatsInTopN counts, per post of dataKsIds, the buckets of the filter before before
+// in which it was ranked at or above topN, by data ks id.
+func (r *Repo) StatsInTopN(f StatsFilter, before time.Time, topN int32, dataKsIds []string) (map[string]int64, error) {
+	q, err := f.statsQuery()
+	if err != nil {
+		return nil, err
+	}
+	where := q.from + fmt.Sprintf(`
+		and data_ks_id = any(%s)
+		and %s < %s
+		and %s <= %s`, q.arg(dataKsIds), q.bucket, q.arg(before), q.rank, q.arg(topN))
+	return r.queryCounts(fmt.Sprintf(`select data_ks_id, count(*)
+		from %s
+		group by data_ks_id
+;`, where), q.args...)
+}
+
+func (r *Repo) queryCounts(query string, args ...any) (map[string]int64, error) {
+	rows, err := r.conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var dataKsId string
+		var count int64
+		rows.Scan(&dataKsId, &count)
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		counts[dataKsId] = count
+	}
+	return counts, nil
+}
+
+// StatsFirst returns, per post of dataKsIds, its first row of the filter in a bucket after after.
+// A nil after returns the first row; nil dataKsIds returns every post.
+func (r *Repo) StatsFirst(f StatsFilter, after *time.Time, dataKsIds []string) ([]Post, error) {
+	q, err := f.statsQuery()
+	if err != nil {
+		return nil, err
+	}
+	where := q.from + fmt.Sprintf(`
+		and (%[1]s::text[] is null or data_ks_id = any(%[1]s))`, q.arg(dataKsIds))
+	if after != nil {
+		where += fmt.Sprintf(`
+		and %s > %s`, q.bucket, q.arg(*after))
+	}
+	return r.queryPosts(fmt.Sprintf(`select distinct on (data_ks_id) %s
+		from %s
+		order by data_ks_id, %s
+;`, q.columns, where, q.bucket), q.args...)
+}
+
+// StatsLast returns, per post of dataKsIds, its last row of the filter in a bucket before before.
+// nil dataKsIds returns every post.
+func (r *Repo) StatsLast(f StatsFilter, before time.Time, dataKsIds []string) ([]Post, error) {
+	q, err := f.statsQuery()
+	if err != nil {
+		return nil, err
+	}
+	where := q.from + fmt.Sprintf(`
+		and (%[1]s::text[] is null or data_ks_id = any(%[1]s))
+		and %[2]s < %[3]s`, q.arg(dataKsIds), q.bucket, q.arg(before))
+	return r.queryPosts(fmt.Sprintf(`select distinct on (data_ks_id) %s
+		from %s
+		order by data_ks_id, %s desc
+;`, q.columns, where, q.bucket), q.args...)
+}
+
+func (r *Repo) queryPosts(query string, args ...any) ([]Post, error) {
+	rows, err := r.conn.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -154,6 +697,9 @@ func (r *Repo) Stats(name string, orderType OrderByAlgo, fromTime *time.Time, to
 			&t.Rank,
 			&t.RankOrderType,
 			&t.RankOrderForCreatedWithinPast,
+			&t.MinRank, &t.MaxRank, &t.AvgRank,
+			&t.MinScore, &t.MaxScore, &t.AvgScore,
+			&t.PostCreatedAt,
 		)
 		if err := rows.Err(); err != nil {
 			return []Post{}, err
@@ -161,5 +707,72 @@ func (r *Repo) Stats(name string, orderType OrderByAlgo, fromTime *time.Time, to
 		post = append(post, t)
 	}
 	return post, nil
+}
+
+// ErrPostNotFound is returned when no post with the data ks id was ever observed.
+var ErrPostNotFound = errors.New("post not found")
+
+// PostMeta is a row of posts.
+type PostMeta struct {
+	Id            int64
+	DataKsId      string
+	Title         string
+	PermaLinkPath string
+	SubredditId   string
+	SubredditName string
+	AuthorId      string
+	AuthorName    string
+	PostCreatedAt *time.Time
+	FirstSeenAt   time.Time
+	LastSeenAt    time.Time
+}
+
+func (r *Repo) GetPost(dataKsId string) (PostMeta, error) {
+	var t PostMeta
+	err := r.conn.QueryRow(context.Background(), `select id, data_ks_id, title, perma_link_path, subreddit_id, subreddit_name,
+		author_id, author_name, post_created_at, first_seen_at, last_seen_at
+		from posts where data_ks_id = $1`, dataKsId).Scan(&t.Id, &t.DataKsId, &t.Title, &t.PermaLinkPath, &t.SubredditId, &t.SubredditName,
+		&t.AuthorId, &t.AuthorName, &t.PostCreatedAt, &t.FirstSeenAt, &t.LastSeenAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PostMeta{}, ErrPostNotFound
+	}
+	return t, err
+}
+
+// Observation is a row of post_observations.
+type Observation struct {
+	Id                            int64
+	PolledTime                    time.Time
+	PolledTimeRoundedMinute       time.Time
+	RankOrderType                 OrderByAlgo
+	RankOrderForCreatedWithinPast CreatedWithinPast
+	Rank                          int32
+	Score                         *int32
+	CommentCount                  *int32
+}
+
+// GetObservations lists every observation of the post across ranking contexts, by poll bucket.
+func (r *Repo) GetObservations(postId int64) ([]Observation, error) {
+	rows, err := r.conn.Query(context.Background(), `select id, polled_time, polled_time_rounded_min,
+		rank_order_type, rank_order_created_within_past, rank, score, comment_count
+		from post_observations
+		where post_id = $1
+		order by polled_time_rounded_min, rank_order_type, rank_order_created_within_past
+;`, postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	var observations []Observation
+	for rows.Next() {
+		var t Observation
+		rows.Scan(&t.Id, &t.PolledTime, &t.PolledTimeRoundedMinute,
+			&t.RankOrderType, &t.RankOrderForCreatedWithinPast, &t.Rank, &t.Score, &t.CommentCount)
+		if err := rows.Err(); err != nil {
+			return []Observation{}, err
+		}
+		observations = append(observations, t)
+	}
+	return observations, nil
 }
@@ -2,10 +2,19 @@ package task
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrDuplicate is returned when an active task with the same subreddit, interval, order and timeframe exists.
+var ErrDuplicate = errors.New("an active task with the same subreddit, interval, order by and posts created within past already exists")
+
+const uniqueViolation = "23505"
+
 type Repo struct {
 	conn *pgxpool.Pool
 }
@@ -41,10 +50,52 @@ const (
 	OrderByAlgoNew  OrderByAlgo = "new"
 )
 
-func (r *Repo) Create(subRedditName string, itemCount int64, interval Granularity, by OrderByAlgo, itemsCreatedWithin CreatedWithinPast) error {
-	row := r.conn.QueryRow(context.Background(), "insert into tasks(subreddit_name, min_item_count, interval, order_by, posts_created_within_past) VALUES ($1,$2,$3,$4, $5) RETURNING id", subRedditName, itemCount, interval, by, itemsCreatedWithin)
+// CatchUpPolicy decides what happens on startup to the windows a task missed while the server was down.
+type CatchUpPolicy string
+
+const (
+	CatchUpPolicySkip CatchUpPolicy = "skip" // only warn
+	CatchUpPolicyOnce CatchUpPolicy = "once" // one run for the latest missed window
+	CatchUpPolicyAll  CatchUpPolicy = "all"  // one run per missed window, oldest first
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusCompleted Status = "completed" // reached ends_at or max_runs, no longer scheduled
+)
+
+// Limits bound the lifetime of a task. Unset fields do not limit.
+type Limits struct {
+	StartsAt *time.Time
+	EndsAt   *time.Time
+	MaxRuns  *int64
+}
+
+// ScraperOptions tune the scrapes of a task. It is stored as a json document; zero values fall back to the scraper defaults,
+// except ScrollWaitSeconds which is nil for the default so that 0 can mean no wait.
+type ScraperOptions struct {
+	ScrollStrategy    string `json:"scroll_strategy,omitempty"` // ["none", "once", "until_count"]
+	ScrollWaitSeconds *int   `json:"scroll_wait_seconds,omitempty"`
+	MaxScrolls        int    `json:"max_scrolls,omitempty"`
+	MaxItems          int    `json:"max_items,omitempty"`
+	DebugLogEnabled   bool   `json:"debug_log_enabled,omitempty"`
+	CaptureSnapshot   bool   `json:"capture_snapshot,omitempty"`
+	Retries           int    `json:"retries,omitempty"`
+}
+
+const insertTask = "insert into tasks(subreddit_name, min_item_count, interval, order_by, posts_created_within_past, catch_up_policy, starts_at, ends_at, max_runs, scraper_options) VALUES ($1,$2,$3,$4, $5, $6, $7, $8, $9, $10) RETURNING id"
+
+func (r *Repo) Create(t Task) error {
+	row := r.conn.QueryRow(context.Background(), insertTask, t.SubRedditName, t.MinItemCount, t.Interval, t.OrderBy, t.PostsCreatedWithinPast, t.CatchUpPolicy, t.StartsAt, t.EndsAt, t.MaxRuns, t.ScraperOptions)
 	var id int64
-	return row.Scan(&id)
+	err := row.Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrDuplicate
+	}
+	return err
 }
 
 func (r *Repo) Delete(id int64) error {
@@ -59,10 +110,14 @@ type Task struct {
 	Interval               Granularity
 	OrderBy                OrderByAlgo
 	PostsCreatedWithinPast CreatedWithinPast
+	CatchUpPolicy          CatchUpPolicy
+	Limits
+	Status         Status
+	ScraperOptions ScraperOptions
 }
 
 func (r *Repo) GetTasksByInterval(every Granularity) ([]Task, error) {
-	rows, err := r.conn.Query(context.Background(), "select id, subreddit_name, min_item_count, interval, order_by, posts_created_within_past from tasks where interval = $1", every)
+	rows, err := r.conn.Query(context.Background(), "select id, subreddit_name, min_item_count, interval, order_by, posts_created_within_past, catch_up_policy, starts_at, ends_at, max_runs, status, scraper_options from tasks where interval = $1 and status = 'active'", every)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +126,7 @@ func (r *Repo) GetTasksByInterval(every Granularity) ([]Task, error) {
 	var tasks []Task
 	for rows.Next() {
 		var t Task
-		rows.Scan(&t.Id, &t.SubRedditName, &t.MinItemCount, &t.Interval, &t.OrderBy, &t.PostsCreatedWithinPast)
+		rows.Scan(&t.Id, &t.SubRedditName, &t.MinItemCount, &t.Interval, &t.OrderBy, &t.PostsCreatedWithinPast, &t.CatchUpPolicy, &t.StartsAt, &t.EndsAt, &t.MaxRuns, &t.Status, &t.ScraperOptions)
 		if err := rows.Err(); err != nil {
 			return []Task{}, err
 		}
@@ -81,7 +136,7 @@ func (r *Repo) GetTasksByInterval(every Granularity) ([]Task, error) {
 }
 
 func (r *Repo) GetTasks() ([]Task, error) {
-	rows, err := r.conn.Query(context.Background(), "select id, subreddit_name, min_item_count, interval, order_by, posts_created_within_past from tasks")
+	rows, err := r.conn.Query(context.Background(), "select id, subreddit_name, min_item_count, interval, order_by, posts_created_within_past, catch_up_policy, starts_at, ends_at, max_runs, status, scraper_options from tasks")
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +145,7 @@ func (r *Repo) GetTasks() ([]Task, error) {
 	var task []Task
 	for rows.Next() {
 		var t Task
-		rows.Scan(&t.Id, &t.SubRedditName, &t.MinItemCount, &t.Interval, &t.OrderBy, &t.PostsCreatedWithinPast)
+		rows.Scan(&t.Id, &t.SubRedditName, &t.MinItemCount, &t.Interval, &t.OrderBy, &t.PostsCreatedWithinPast, &t.CatchUpPolicy, &t.StartsAt, &t.EndsAt, &t.MaxRuns, &t.Status, &t.ScraperOptions)
 		if err := rows.Err(); err != nil {
 			return []Task{}, err
 		}
@@ -98,3 +153,135 @@ func (r *Repo) GetTasks() ([]Task, error) {
 	}
 	return task, nil
 }
+
+func (r *Repo) UpdateScraperOptions(id int64, options ScraperOptions) error {
+	tag, err := r.conn.Exec(context.Background(), "update tasks set scraper_options = $2 where id = $1", id, options)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("task %d not found", id)
+	}
+	return nil
+}
+
+func (r *Repo) Complete(id int64) error {
+	_, err := r.conn.Exec(context.Background(), "update tasks set status = $2 where id = $1", id, StatusCompleted)
+	return err
+}
+
+// CountRuns counts the windows of the task's interval with a succeeded run.
+// The runs of every minute within the same window count once.
+func (r *Repo) CountRuns(id int64) (int64, error) {
+	row := r.conn.QueryRow(context.Background(), `select count(distinct date_trunc(t.interval, r.scheduled_at at time zone 'UTC'))
+		from task_runs r
+		join tasks t on t.id = r.task_id
+		where r.task_id = $1
+		and r.status = 'succeeded'
+;`, id)
+	var count int64
+	return count, row.Scan(&count)
+}
+
+type MissedWindow struct {
+	TaskId      int64
+	WindowStart time.Time
+}
+
+// GetMissedWindows lists, per task of the interval, the windows of length every between since and until without a succeeded run.
+// A task is only expected to have run from its first recorded run onwards.
+func (r *Repo) GetMissedWindows(interval Granularity, every time.Duration, since time.Time, until time.Time) ([]MissedWindow, error) {
+	rows, err := r.conn.Query(context.Background(), `select t.id,
+		w.window_start
+		from tasks t
+		cross join lateral (select min(scheduled_at) as first_run from task_runs where task_id = t.id) f
+		cross join lateral generate_series(
+			to_timestamp(floor(extract(epoch from greatest(f.first_run, $2)) / extract(epoch from $4::interval)) * extract(epoch from $4::interval)),
+			$3::timestamptz - $4::interval,
+			$4::interval
+		) w(window_start)
+		where t.interval = $1
+		and t.status = 'active'
+		and f.first_run is not null
+		and (t.starts_at is null or w.window_start >= t.starts_at)
+		and (t.ends_at is null or w.window_start < t.ends_at)
+		and not exists (select 1
+			from task_runs r
+			where r.task_id = t.id
+			and r.status = 'succeeded'
+			and r.scheduled_at >= w.window_start
+			and r.scheduled_at < w.window_start + $4::interval)
+		order by t.id, w.window_start
+;`, interval, since, until, every)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []MissedWindow
+	for rows.Next() {
+		var t MissedWindow
+		rows.Scan(&t.TaskId, &t.WindowStart)
+		if err := rows.Err(); err != nil {
+			return []MissedWindow{}, err
+		}
+		windows = append(windows, t)
+	}
+	return windows, nil
+}
+
+func (r *Repo) GetActiveTasks() ([]Task, error) {
+	rows, err := r.conn.Query(context.Background(), "select id, subreddit_name, min_item_count, interval, order_by, posts_created_within_past, catch_up_policy, starts_at, ends_at, max_runs, status, scraper_options from tasks where status = 'active' order by id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var t Task
+		rows.Scan(&t.Id, &t.SubRedditName, &t.MinItemCount, &t.Interval, &t.OrderBy, &t.PostsCreatedWithinPast, &t.CatchUpPolicy, &t.StartsAt, &t.EndsAt, &t.MaxRuns, &t.Status, &t.ScraperOptions)
+		if err := rows.Err(); err != nil {
+			return []Task{}, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+// Sync creates, updates (by id) and deletes tasks in one transaction.
+func (r *Repo) Sync(creates []Task, updates []Task, deletes []int64) error {
+	ctx := context.Background()
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, id := range deletes {
+		_, err := tx.Exec(ctx, "DELETE FROM tasks where id=$1", id)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range updates {
+		_, err := tx.Exec(ctx, "update tasks set subreddit_name = $2, min_item_count = $3, catch_up_policy = $4, starts_at = $5, ends_at = $6, max_runs = $7, scraper_options = $8 where id = $1", t.Id, t.SubRedditName, t.MinItemCount, t.CatchUpPolicy, t.StartsAt, t.EndsAt, t.MaxRuns, t.ScraperOptions)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range creates {
+		_, err := tx.Exec(ctx, insertTask, t.SubRedditName, t.MinItemCount, t.Interval, t.OrderBy, t.PostsCreatedWithinPast, t.CatchUpPolicy, t.StartsAt, t.EndsAt, t.MaxRuns, t.ScraperOptions)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrDuplicate
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
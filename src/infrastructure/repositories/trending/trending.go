@@ -0,0 +1,215 @@
+package trending
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repo struct {
+	conn *pgxpool.Pool
+}
+
+func New(conn *pgxpool.Pool) *Repo {
+	return &Repo{
+		conn: conn,
+	}
+}
+
+// Velocity is a post's change since its previous poll, at the latest poll of the ranking context.
+type Velocity struct {
+	PostId        int64
+	DataKsId      string
+	Title         string
+	PermaLinkPath string
+	SubredditName string
+	Bucket        time.Time
+	Rank          int32
+	Score         *int32
+	ScoreVelocity *float64 // per hour
+	RankVelocity  float64  // ranks climbed per hour
+}
+
+// GetVelocities compares the latest poll of the subreddit and ranking context since the time with the poll before it.
+func (r *Repo) GetVelocities(subredditName string, orderType string, past string, since time.Time) ([]Velocity, error) {
+	rows, err := r.conn.Query(context.Background(), `with polls as (
+		select distinct o.polled_time_rounded_min as bucket
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and o.polled_time_rounded_min >= $4
+		order by bucket desc
+		limit 2
+	),
+	v as (
+		select o.post_id,
+		p.data_ks_id,
+		p.title,
+		p.perma_link_path,
+		p.subreddit_name,
+		o.polled_time_rounded_min as bucket,
+		o.rank,
+		o.score,
+		extract(epoch from o.polled_time - lag(o.polled_time) over w)::double precision / 3600 as hours,
+		o.score - lag(o.score) over w as score_delta,
+		lag(o.rank) over w - o.rank as rank_delta
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and o.polled_time_rounded_min in (select bucket from polls)
+		window w as (partition by o.post_id order by o.polled_time_rounded_min)
+	)
+	select post_id, data_ks_id, title, perma_link_path, subreddit_name, bucket, rank, score,
+	score_delta / hours, rank_delta / hours
+	from v
+	where hours > 0
+	and bucket = (select max(bucket) from polls)
+;`, subredditName, orderType, past, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var velocities []Velocity
+	for rows.Next() {
+		var t Velocity
+		rows.Scan(&t.PostId, &t.DataKsId, &t.Title, &t.PermaLinkPath, &t.SubredditName, &t.Bucket, &t.Rank, &t.Score,
+			&t.ScoreVelocity, &t.RankVelocity)
+		if err := rows.Err(); err != nil {
+			return []Velocity{}, err
+		}
+		velocities = append(velocities, t)
+	}
+	return velocities, nil
+}
+
+// Baseline summarises the changes of a ranking context between consecutive polls of a post.
+type Baseline struct {
+	Samples     int64
+	ScoreMean   *float64
+	ScoreStdDev *float64
+	RankMean    *float64
+	RankStdDev  *float64
+}
+
+// GetBaseline summarises every change of the subreddit and ranking context since the time, before its latest poll.
+func (r *Repo) GetBaseline(subredditName string, orderType string, past string, since time.Time) (Baseline, error) {
+	row := r.conn.QueryRow(context.Background(), `with v as (
+		select o.polled_time_rounded_min as bucket,
+		extract(epoch from o.polled_time - lag(o.polled_time) over w)::double precision / 3600 as hours,
+		o.score - lag(o.score) over w as score_delta,
+		lag(o.rank) over w - o.rank as rank_delta
+		from post_observations o
+		join posts p on p.id = o.post_id
+		where lower(p.subreddit_name) = lower($1)
+		and o.rank_order_type = $2
+		and o.rank_order_created_within_past = $3
+		and o.polled_time_rounded_min >= $4
+		window w as (partition by o.post_id order by o.polled_time_rounded_min)
+	),
+	vel as (
+		select bucket, score_delta / hours as score_velocity, rank_delta / hours as rank_velocity
+		from v
+		where hours > 0
+	)
+	select count(*),
+	avg(score_velocity),
+	stddev_samp(score_velocity),
+	avg(rank_velocity),
+	stddev_samp(rank_velocity)
+	from vel
+	where bucket < (select max(bucket) from vel)
+;`, subredditName, orderType, past, since)
+	var b Baseline
+	return b, row.Scan(&b.Samples, &b.ScoreMean, &b.ScoreStdDev, &b.RankMean, &b.RankStdDev)
+}
+
+// Event is also the payload of the trending webhook.
+type Event struct {
+	Id                            int64     `json:"id"`
+	DetectedAt                    time.Time `json:"detected_at"`
+	PostId                        int64     `json:"-"`
+	DataKsId                      string    `json:"data_ks_id"`
+	Title                         string    `json:"title"`
+	PermaLinkPath                 string    `json:"perma_link_path"`
+	SubredditName                 string    `json:"subreddit_name"`
+	RankOrderType                 string    `json:"rank_order_type"`
+	RankOrderForCreatedWithinPast string    `json:"rank_order_created_within_past"`
+	PolledTimeRoundedMinute       time.Time `json:"polled_time_rounded_min"`
+	Rank                          int32     `json:"rank"`
+	Score                         *int32    `json:"score"`
+	ScoreVelocity                 *float64  `json:"score_velocity"` // per hour
+	RankVelocity                  *float64  `json:"rank_velocity"`  // ranks climbed per hour
+	ScoreZ                        *float64  `json:"score_z"`
+	RankZ                         *float64  `json:"rank_z"`
+	Threshold                     float64   `json:"threshold"`
+}
+
+// InsertEvents stores the events and returns the ones stored, skipping events already stored for the post, bucket and ranking context.
+func (r *Repo) InsertEvents(events []Event) ([]Event, error) {
+	ctx := context.Background()
+	batch := &pgx.Batch{}
+	for _, e := range events {
+		batch.Queue(`insert into trending_events(post_id, subreddit_name, rank_order_type, rank_order_created_within_past,
+			polled_time_rounded_min, rank, score, score_velocity, rank_velocity, score_z, rank_z, threshold)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+			on conflict (post_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past) do nothing
+			RETURNING id, detected_at`,
+			e.PostId, e.SubredditName, e.RankOrderType, e.RankOrderForCreatedWithinPast,
+			e.PolledTimeRoundedMinute, e.Rank, e.Score, e.ScoreVelocity, e.RankVelocity, e.ScoreZ, e.RankZ, e.Threshold)
+	}
+
+	results := r.conn.SendBatch(ctx, batch)
+	defer results.Close()
+
+	var stored []Event
+	for _, e := range events {
+		err := results.QueryRow().Scan(&e.Id, &e.DetectedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, e)
+	}
+	return stored, nil
+}
+
+// GetEvents lists the events of the subreddit detected at or after the time, newest first.
+func (r *Repo) GetEvents(subredditName string, since time.Time, limit int) ([]Event, error) {
+	rows, err := r.conn.Query(context.Background(), `select e.id, e.detected_at, e.post_id, p.data_ks_id, p.title, p.perma_link_path,
+		e.subreddit_name, e.rank_order_type, e.rank_order_created_within_past, e.polled_time_rounded_min,
+		e.rank, e.score, e.score_velocity, e.rank_velocity, e.score_z, e.rank_z, e.threshold
+		from trending_events e
+		join posts p on p.id = e.post_id
+		where lower(e.subreddit_name) = lower($1)
+		and e.polled_time_rounded_min >= $2
+		order by e.polled_time_rounded_min desc, e.id desc
+		limit $3
+;`, subredditName, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var t Event
+		rows.Scan(&t.Id, &t.DetectedAt, &t.PostId, &t.DataKsId, &t.Title, &t.PermaLinkPath,
+			&t.SubredditName, &t.RankOrderType, &t.RankOrderForCreatedWithinPast, &t.PolledTimeRoundedMinute,
+			&t.Rank, &t.Score, &t.ScoreVelocity, &t.RankVelocity, &t.ScoreZ, &t.RankZ, &t.Threshold)
+		if err := rows.Err(); err != nil {
+			return []Event{}, err
+		}
+		events = append(events, t)
+	}
+	return events, nil
+}
@@ -0,0 +1,260 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrDuplicate is returned when a watchlist with the same name exists.
+var ErrDuplicate = errors.New("a watchlist with the same name already exists")
+
+// ErrNotFound is returned when no watchlist has the id.
+var ErrNotFound = errors.New("watchlist not found")
+
+const uniqueViolation = "23505"
+
+type Repo struct {
+	conn *pgxpool.Pool
+}
+
+func New(conn *pgxpool.Pool) *Repo {
+	return &Repo{
+		conn: conn,
+	}
+}
+
+type Keyword struct {
+	Id            int64
+	Keyword       string
+	IsRegex       bool
+	CaseSensitive bool
+}
+
+type Watchlist struct {
+	Id         int64
+	Name       string
+	Subreddits []string // lower case, empty for every subreddit
+	Keywords   []Keyword
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Create stores the watchlist and its keywords and returns its id.
+func (r *Repo) Create(w Watchlist) (int64, error) {
+	ctx := context.Background()
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	err = tx.QueryRow(ctx, "insert into watchlists(name, subreddits) VALUES ($1, $2) RETURNING id", w.Name, w.Subreddits).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return 0, ErrDuplicate
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := insertKeywords(ctx, tx, id, w.Keywords); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+// Update replaces the name, subreddits and keywords of the watchlist.
+// Keywords kept unchanged keep their id and so their matches; the matches of removed keywords are deleted with them.
+func (r *Repo) Update(w Watchlist) error {
+	ctx := context.Background()
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "update watchlists set name = $2, subreddits = $3, updated_at = now() where id = $1", w.Id, w.Name, w.Subreddits)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	var keywords []string
+	var isRegex, caseSensitive []bool
+	for _, k := range w.Keywords {
+		keywords = append(keywords, k.Keyword)
+		isRegex = append(isRegex, k.IsRegex)
+		caseSensitive = append(caseSensitive, k.CaseSensitive)
+	}
+	_, err = tx.Exec(ctx, `delete from watchlist_keywords
+		where watchlist_id = $1
+		and (keyword, is_regex, case_sensitive) not in (select * from unnest($2::text[], $3::boolean[], $4::boolean[]))`,
+		w.Id, keywords, isRegex, caseSensitive)
+	if err != nil {
+		return err
+	}
+	if err := insertKeywords(ctx, tx, w.Id, w.Keywords); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func insertKeywords(ctx context.Context, tx pgx.Tx, watchlistId int64, keywords []Keyword) error {
+	for _, k := range keywords {
+		_, err := tx.Exec(ctx, `insert into watchlist_keywords(watchlist_id, keyword, is_regex, case_sensitive) VALUES ($1, $2, $3, $4)
+			on conflict (watchlist_id, keyword, is_regex, case_sensitive) do nothing`,
+			watchlistId, k.Keyword, k.IsRegex, k.CaseSensitive)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the watchlist with its keywords and matches.
+func (r *Repo) Delete(id int64) error {
+	tag, err := r.conn.Exec(context.Background(), "DELETE FROM watchlists where id=$1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Get returns the watchlist with its keywords.
+func (r *Repo) Get(id int64) (Watchlist, error) {
+	watchlists, err := r.getWatchlists(id)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	if len(watchlists) == 0 {
+		return Watchlist{}, ErrNotFound
+	}
+	return watchlists[0], nil
+}
+
+// GetWatchlists lists every watchlist with its keywords, by id.
+func (r *Repo) GetWatchlists() ([]Watchlist, error) {
+	return r.getWatchlists(0)
+}
+
+// getWatchlists lists the watchlist of the id, or every watchlist for id 0.
+func (r *Repo) getWatchlists(id int64) ([]Watchlist, error) {
+	rows, err := r.conn.Query(context.Background(), `select w.id, w.name, w.subreddits, w.created_at, w.updated_at,
+		k.id, k.keyword, k.is_regex, k.case_sensitive
+		from watchlists w
+		left join watchlist_keywords k on k.watchlist_id = w.id
+		where $1 = 0 or w.id = $1
+		order by w.id, k.id
+;`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watchlists []Watchlist
+	for rows.Next() {
+		var w Watchlist
+		var keywordId *int64
+		var keyword *string
+		var isRegex, caseSensitive *bool
+		rows.Scan(&w.Id, &w.Name, &w.Subreddits, &w.CreatedAt, &w.UpdatedAt, &keywordId, &keyword, &isRegex, &caseSensitive)
+		if err := rows.Err(); err != nil {
+			return []Watchlist{}, err
+		}
+		if len(watchlists) == 0 || watchlists[len(watchlists)-1].Id != w.Id {
+			watchlists = append(watchlists, w)
+		}
+		if keywordId != nil {
+			last := &watchlists[len(watchlists)-1]
+			last.Keywords = append(last.Keywords, Keyword{Id: *keywordId, Keyword: *keyword, IsRegex: *isRegex, CaseSensitive: *caseSensitive})
+		}
+	}
+	return watchlists, nil
+}
+
+// Match is a keyword found in the title of a scraped post.
+type Match struct {
+	KeywordId                     int64
+	DataKsId                      string
+	SubredditName                 string
+	RankOrderType                 string
+	RankOrderForCreatedWithinPast string
+	PolledTimeRoundedMinute       time.Time
+	Rank                          int32
+	Score                         *int32
+}
+
+// InsertMatches stores the matches of stored posts, skipping matches already stored for the keyword, post, bucket and ranking context.
+func (r *Repo) InsertMatches(matches []Match) error {
+	ctx := context.Background()
+	batch := &pgx.Batch{}
+	for _, m := range matches {
+		batch.Queue(`insert into watchlist_matches(keyword_id, post_id, subreddit_name, rank_order_type, rank_order_created_within_past,
+			polled_time_rounded_min, rank, score)
+			select $1, p.id, $3, $4, $5, $6, $7, $8
+			from posts p
+			where p.data_ks_id = $2
+			on conflict (keyword_id, post_id, polled_time_rounded_min, rank_order_type, rank_order_created_within_past) do nothing`,
+			m.KeywordId, m.DataKsId, m.SubredditName, m.RankOrderType, m.RankOrderForCreatedWithinPast,
+			m.PolledTimeRoundedMinute, m.Rank, m.Score)
+	}
+	return r.conn.SendBatch(ctx, batch).Close()
+}
+
+// KeywordBucket counts the matches of a keyword over one bucket.
+type KeywordBucket struct {
+	KeywordId int64
+	Bucket    time.Time
+	Mentions  int64 // distinct posts matched
+	InTopN    int64 // distinct posts matched at or above the top n rank
+	BestRank  int32
+}
+
+// Series counts the matches of the watchlist's keywords polled in [from, to) per bucket of the step,
+// in the ranking context, or in every context when orderType and past are empty.
+func (r *Repo) Series(watchlistId int64, step time.Duration, from time.Time, to time.Time, orderType string, past string, topN int32) ([]KeywordBucket, error) {
+	rows, err := r.conn.Query(context.Background(), `select m.keyword_id,
+		date_bin(make_interval(secs => $2), m.polled_time_rounded_min, timestamptz '2001-01-01 00:00:00+00') as bucket,
+		count(distinct m.post_id),
+		count(distinct m.post_id) filter (where m.rank <= $7),
+		min(m.rank)
+		from watchlist_matches m
+		join watchlist_keywords k on k.id = m.keyword_id
+		where k.watchlist_id = $1
+		and $3 <= m.polled_time_rounded_min
+		and m.polled_time_rounded_min < $4
+		and ($5 = '' or m.rank_order_type = $5)
+		and ($6 = '' or m.rank_order_created_within_past = $6)
+		group by 1, 2
+		order by 1, 2
+;`, watchlistId, step.Seconds(), from, to, orderType, past, topN)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []KeywordBucket
+	for rows.Next() {
+		var t KeywordBucket
+		rows.Scan(&t.KeywordId, &t.Bucket, &t.Mentions, &t.InTopN, &t.BestRank)
+		if err := rows.Err(); err != nil {
+			return []KeywordBucket{}, err
+		}
+		buckets = append(buckets, t)
+	}
+	return buckets, nil
+}
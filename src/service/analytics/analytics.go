@@ -0,0 +1,46 @@
+package analytics
+
+import (
+	"fmt"
+	"time"
+
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+)
+
+type Service struct {
+	repo *analyticsrepo.Repo
+}
+
+func New(repo *analyticsrepo.Repo) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// Scope is the subreddit, ranking context and poll time range an analysis covers.
+type Scope struct {
+	SubredditName string
+	OrderType     string
+	Past          string
+	From          time.Time
+	To            time.Time
+}
+
+// MaxRange bounds the poll time range of an analysis.
+const MaxRange = 92 * 24 * time.Hour
+
+func (s Scope) validate() error {
+	if s.SubredditName == "" {
+		return fmt.Errorf("empty subreddit name")
+	}
+	if s.OrderType == "" || s.Past == "" {
+		return fmt.Errorf("empty ranking context. rank_order_type %v, rank_order_created_within_past %v", s.OrderType, s.Past)
+	}
+	if !s.From.Before(s.To) {
+		return fmt.Errorf("from time %s is not before to time %s", s.From, s.To)
+	}
+	if s.To.Sub(s.From) > MaxRange {
+		return fmt.Errorf("time range %s is longer than %s", s.To.Sub(s.From), MaxRange)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Weekdays are the rows of a BestTime matrix, Monday first.
+var Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// BestTimeCell is the outcome of the posts created in one hour of the week.
+type BestTimeCell struct {
+	Posts           int
+	ReachedTopN     int
+	Probability     *float64 // ReachedTopN over Posts, nil without posts
+	MedianPeakScore *float64 // nil without scored posts
+}
+
+type BestTime struct {
+	Location *time.Location
+	TopN     int32
+	Cells    [7][24]BestTimeCell // by Weekdays index, then hour of the day in Location
+}
+
+// BestTime buckets the posts created within the scope's time range by their creation hour of the week in loc.
+// For each bucket it reports the share of posts that reached rank topN in the ranking context and their median peak score.
+func (s Service) BestTime(scope Scope, topN int32, loc *time.Location) (BestTime, error) {
+	if err := scope.validate(); err != nil {
+		return BestTime{}, err
+	}
+	if topN < 1 {
+		return BestTime{}, fmt.Errorf("top n %d not supported, need at least 1", topN)
+	}
+
+	outcomes, err := s.repo.PostOutcomes(scope.SubredditName, scope.OrderType, scope.Past, scope.From, scope.To)
+	if err != nil {
+		return BestTime{}, err
+	}
+
+	var peakScores [7][24][]float64
+	bt := BestTime{Location: loc, TopN: topN}
+	for _, o := range outcomes {
+		t := o.PostCreatedAt.In(loc)
+		day := (int(t.Weekday()) + 6) % 7 // Monday first
+		cell := &bt.Cells[day][t.Hour()]
+		cell.Posts++
+		if o.BestRank <= topN {
+			cell.ReachedTopN++
+		}
+		if o.PeakScore != nil {
+			peakScores[day][t.Hour()] = append(peakScores[day][t.Hour()], float64(*o.PeakScore))
+		}
+	}
+	for day := range bt.Cells {
+		for hour := range bt.Cells[day] {
+			cell := &bt.Cells[day][hour]
+			if cell.Posts > 0 {
+				p := float64(cell.ReachedTopN) / float64(cell.Posts)
+				cell.Probability = &p
+			}
+			cell.MedianPeakScore = median(peakScores[day][hour])
+		}
+	}
+	return bt, nil
+}
+
+func median(v []float64) *float64 {
+	if len(v) == 0 {
+		return nil
+	}
+	slices.Sort(v)
+	m := v[len(v)/2]
+	if len(v)%2 == 0 {
+		m = (v[len(v)/2-1] + m) / 2
+	}
+	return &m
+}
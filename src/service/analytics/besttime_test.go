@@ -0,0 +1,23 @@
+package analytics
+
+import "testing"
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name string
+		v    []float64
+		want *float64
+	}{
+		{"empty", nil, nil},
+		{"one", []float64{4}, f(4)},
+		{"odd", []float64{9, 1, 5}, f(5)},
+		{"even", []float64{8, 2, 4, 6}, f(5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := median(tt.v); !near(got, tt.want) {
+				t.Fatalf("median = %v, want %v", show(got), show(tt.want))
+			}
+		})
+	}
+}
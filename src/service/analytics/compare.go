@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+	statisticsservice "github.com/noellimx/redditminer/src/service/statistics"
+)
+
+const (
+	MaxCompareSubreddits = 10
+	MaxCompareBuckets    = 5000
+)
+
+// CompareSeries are the aggregates of one subreddit, aligned with Comparison.Buckets. Nil where there is no data.
+type CompareSeries struct {
+	SubredditName         string
+	MeanScoreTopN         []*float64
+	MedianCommentsTopN    []*float64
+	TurnoverRate          []*float64 // share of the top n posts of a bucket that were not in the top n of the previous bucket
+	AvgPostAgeAtPeakHours []*float64 // average age in hours of the posts peaking in the bucket
+}
+
+type Comparison struct {
+	Buckets []time.Time
+	Series  []CompareSeries // in the order the subreddits were asked for
+}
+
+// Compare aligns the top n aggregates of the subreddits on buckets of the granularity.
+// scope.SubredditName is ignored.
+func (s Service) Compare(subredditNames []string, scope Scope, granularity statisticsrepo.Granularity, topN int32) (Comparison, error) {
+	if len(subredditNames) == 0 || len(subredditNames) > MaxCompareSubreddits {
+		return Comparison{}, fmt.Errorf("%d subreddits not supported, need 1 to %d", len(subredditNames), MaxCompareSubreddits)
+	}
+	var names []string
+	for _, name := range subredditNames {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || slices.Contains(names, name) {
+			return Comparison{}, fmt.Errorf("subreddits must be distinct and not empty")
+		}
+		names = append(names, name)
+	}
+	scope.SubredditName = strings.Join(names, ",")
+	if err := scope.validate(); err != nil {
+		return Comparison{}, err
+	}
+	step, ok := statisticsservice.GranularityToDuration[granularity]
+	if !ok {
+		return Comparison{}, fmt.Errorf("granularity type not supported. =%d", granularity)
+	}
+	if topN < 1 {
+		return Comparison{}, fmt.Errorf("top n %d not supported, need at least 1", topN)
+	}
+
+	var buckets []time.Time
+	for t := scope.From.UTC().Truncate(step); t.Before(scope.To); t = t.Add(step) {
+		buckets = append(buckets, t)
+		if len(buckets) > MaxCompareBuckets {
+			return Comparison{}, fmt.Errorf("more than %d buckets, use a coarser granularity or a shorter range", MaxCompareBuckets)
+		}
+	}
+	index := make(map[time.Time]int)
+	for i, t := range buckets {
+		index[t] = i
+	}
+
+	topNBuckets, err := s.repo.TopNBuckets(names, step, scope.OrderType, scope.Past, scope.From, scope.To, topN)
+	if err != nil {
+		return Comparison{}, err
+	}
+	peakAgeBuckets, err := s.repo.PeakAgeBuckets(names, step, scope.OrderType, scope.Past, scope.From, scope.To)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	series := make(map[string]*CompareSeries)
+	comparison := Comparison{Buckets: buckets}
+	for _, name := range names {
+		comparison.Series = append(comparison.Series, CompareSeries{
+			SubredditName:         name,
+			MeanScoreTopN:         make([]*float64, len(buckets)),
+			MedianCommentsTopN:    make([]*float64, len(buckets)),
+			TurnoverRate:          make([]*float64, len(buckets)),
+			AvgPostAgeAtPeakHours: make([]*float64, len(buckets)),
+		})
+	}
+	for i := range comparison.Series {
+		series[comparison.Series[i].SubredditName] = &comparison.Series[i]
+	}
+
+	members := make(map[string][]map[int64]struct{})
+	for _, name := range names {
+		members[name] = make([]map[int64]struct{}, len(buckets))
+	}
+	for _, b := range topNBuckets {
+		i, ok := index[b.Bucket.UTC()]
+		if !ok {
+			continue
+		}
+		cs := series[b.SubredditName]
+		cs.MeanScoreTopN[i] = b.MeanScore
+		cs.MedianCommentsTopN[i] = b.MedianCommentCount
+		set := make(map[int64]struct{})
+		for _, id := range b.PostIds {
+			set[id] = struct{}{}
+		}
+		members[b.SubredditName][i] = set
+	}
+	for name, sets := range members {
+		for i := 1; i < len(sets); i++ {
+			if sets[i] == nil || sets[i-1] == nil {
+				continue
+			}
+			entered := 0
+			for id := range sets[i] {
+				if _, ok := sets[i-1][id]; !ok {
+					entered++
+				}
+			}
+			rate := float64(entered) / float64(len(sets[i]))
+			series[name].TurnoverRate[i] = &rate
+		}
+	}
+
+	for _, b := range peakAgeBuckets {
+		i, ok := index[b.Bucket.UTC()]
+		if !ok {
+			continue
+		}
+		hours := b.AvgHoursAtPeak
+		series[b.SubredditName].AvgPostAgeAtPeakHours[i] = &hours
+	}
+	return comparison, nil
+}
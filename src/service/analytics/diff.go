@@ -0,0 +1,146 @@
+package analytics
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+)
+
+type ChangeType string
+
+const (
+	ChangeEntered ChangeType = "entered" // in the at poll only
+	ChangeDropped ChangeType = "dropped" // in the vs poll only
+	ChangeMoved   ChangeType = "moved"   // in both, at a different rank
+)
+
+// Change of a post between the vs poll and the at poll. Rank and score fields of the poll the post is missing from are nil.
+type Change struct {
+	Type          ChangeType
+	DataKsId      string
+	Title         string
+	PermaLinkPath string
+	AuthorName    string
+
+	RankAt     *int32
+	RankVs     *int32
+	RankDelta  *int32 // RankVs minus RankAt, positive when the post moved up
+	ScoreAt    *int32
+	ScoreVs    *int32
+	ScoreDelta *int32
+}
+
+type Diff struct {
+	AtPoll    time.Time // nearest poll to the at time
+	VsPoll    time.Time // nearest poll to the vs time
+	Entered   []Change  // by rank at
+	Dropped   []Change  // by rank vs
+	Movers    []Change  // by the size of the move, largest first
+	Unchanged int
+}
+
+// Diff compares the nearest polls to the at and vs times of the subreddit and ranking context,
+// considering the posts ranked at or above topN in either poll, or every post when topN is 0.
+func (s Service) Diff(subredditName string, orderType string, past string, at time.Time, vs time.Time, topN int32) (Diff, error) {
+	if subredditName == "" {
+		return Diff{}, fmt.Errorf("empty subreddit name")
+	}
+	if orderType == "" || past == "" {
+		return Diff{}, fmt.Errorf("empty ranking context. rank_order_type %v, rank_order_created_within_past %v", orderType, past)
+	}
+	if topN < 0 {
+		return Diff{}, fmt.Errorf("top n %d not supported, need 0 for every post or more", topN)
+	}
+
+	atPoll, atPosts, err := s.nearestPoll(subredditName, orderType, past, at, topN)
+	if err != nil {
+		return Diff{}, err
+	}
+	vsPoll, vsPosts, err := s.nearestPoll(subredditName, orderType, past, vs, topN)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	diff := Diff{AtPoll: atPoll, VsPoll: vsPoll}
+	vsById := make(map[string]analyticsrepo.RankedPost)
+	for _, p := range vsPosts {
+		vsById[p.DataKsId] = p
+	}
+	atById := make(map[string]analyticsrepo.RankedPost)
+	for _, p := range atPosts {
+		atById[p.DataKsId] = p
+
+		vp, ok := vsById[p.DataKsId]
+		if !ok {
+			diff.Entered = append(diff.Entered, change(ChangeEntered, &p, nil))
+			continue
+		}
+		if vp.Rank == p.Rank {
+			diff.Unchanged++
+			continue
+		}
+		diff.Movers = append(diff.Movers, change(ChangeMoved, &p, &vp))
+	}
+	for _, p := range vsPosts {
+		if _, ok := atById[p.DataKsId]; !ok {
+			diff.Dropped = append(diff.Dropped, change(ChangeDropped, nil, &p))
+		}
+	}
+
+	slices.SortStableFunc(diff.Movers, func(a, b Change) int {
+		return cmp.Compare(abs(*b.RankDelta), abs(*a.RankDelta))
+	})
+	return diff, nil
+}
+
+func (s Service) nearestPoll(subredditName string, orderType string, past string, t time.Time, topN int32) (time.Time, []analyticsrepo.RankedPost, error) {
+	bucket, err := s.repo.NearestPoll(subredditName, orderType, past, t)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	posts, err := s.repo.GetPoll(subredditName, orderType, past, bucket)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if topN > 0 {
+		posts = slices.DeleteFunc(posts, func(p analyticsrepo.RankedPost) bool {
+			return p.Rank > topN
+		})
+	}
+	return bucket, posts, nil
+}
+
+func change(t ChangeType, at *analyticsrepo.RankedPost, vs *analyticsrepo.RankedPost) Change {
+	c := Change{Type: t}
+	meta := at
+	if meta == nil {
+		meta = vs
+	}
+	c.DataKsId, c.Title, c.PermaLinkPath, c.AuthorName = meta.DataKsId, meta.Title, meta.PermaLinkPath, meta.AuthorName
+
+	if at != nil {
+		c.RankAt, c.ScoreAt = &at.Rank, at.Score
+	}
+	if vs != nil {
+		c.RankVs, c.ScoreVs = &vs.Rank, vs.Score
+	}
+	if at != nil && vs != nil {
+		rankDelta := vs.Rank - at.Rank
+		c.RankDelta = &rankDelta
+		if at.Score != nil && vs.Score != nil {
+			scoreDelta := *at.Score - *vs.Score
+			c.ScoreDelta = &scoreDelta
+		}
+	}
+	return c
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
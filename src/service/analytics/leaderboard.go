@@ -0,0 +1,26 @@
+package analytics
+
+import (
+	"fmt"
+
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+)
+
+const MaxLeaderboardLimit = 1000
+
+// Leaderboard ranks the authors of the scope by the metric, counting ranks at or above topN as in the top.
+func (s Service) Leaderboard(scope Scope, metric analyticsrepo.LeaderboardMetric, topN int32, limit int) ([]analyticsrepo.AuthorStanding, error) {
+	if err := scope.validate(); err != nil {
+		return nil, err
+	}
+	if !metric.Valid() {
+		return nil, fmt.Errorf("metric %s not supported, need posts_in_top_n, hours_in_top_n, best_rank or peak_score", metric)
+	}
+	if topN < 1 {
+		return nil, fmt.Errorf("top n %d not supported, need at least 1", topN)
+	}
+	if limit < 1 || limit > MaxLeaderboardLimit {
+		return nil, fmt.Errorf("limit %d not supported, need 1 to %d", limit, MaxLeaderboardLimit)
+	}
+	return s.repo.Leaderboard(scope.SubredditName, scope.OrderType, scope.Past, scope.From, scope.To, topN, metric, limit)
+}
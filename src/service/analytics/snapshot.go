@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"fmt"
+	"time"
+
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+)
+
+// Snapshot is the ranked listing as stored for one poll.
+type Snapshot struct {
+	SubredditName string
+	OrderType     string
+	Past          string
+	Bucket        time.Time // polled_time_rounded_min of the poll
+	PolledTime    time.Time // exact time of the poll, the earliest when the bucket merged polls
+	RunId         *int64    // task run of the poll, nil outside a run or when the bucket merged polls of different runs
+	Posts         []analyticsrepo.RankedPost
+}
+
+// Snapshot reconstructs the listing of the subreddit and ranking context at the stored poll nearest to the time.
+func (s Service) Snapshot(subredditName string, orderType string, past string, at time.Time) (Snapshot, error) {
+	if subredditName == "" {
+		return Snapshot{}, fmt.Errorf("empty subreddit name")
+	}
+	if orderType == "" || past == "" {
+		return Snapshot{}, fmt.Errorf("empty ranking context. order %v, past %v", orderType, past)
+	}
+
+	bucket, posts, err := s.nearestPoll(subredditName, orderType, past, at, 0)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{
+		SubredditName: subredditName,
+		OrderType:     orderType,
+		Past:          past,
+		Bucket:        bucket,
+		Posts:         posts,
+	}
+	sameRun := true
+	for i, p := range posts {
+		if i == 0 || p.PolledTime.Before(snapshot.PolledTime) {
+			snapshot.PolledTime = p.PolledTime
+		}
+		if i > 0 && !sameRunId(p.RunId, posts[0].RunId) {
+			sameRun = false
+		}
+	}
+	if sameRun && len(posts) > 0 {
+		snapshot.RunId = posts[0].RunId
+	}
+	return snapshot, nil
+}
+
+func sameRunId(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,214 @@
+package analytics
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxStabilityPolls bounds the polls a stability analysis reads.
+const MaxStabilityPolls = 5000
+
+// PollStability compares a poll with the previous one.
+type PollStability struct {
+	Bucket  time.Time
+	Entered int // posts in the top n that were not in the previous top n
+	Left    int // posts of the previous top n no longer in it
+
+	AvgTenureHours *float64 // how long the posts of the top n have been in it without a break, on average
+
+	CommonPosts int      // posts ranked in both polls, which the correlations are computed over
+	Spearman    *float64 // nil below two common posts
+	Kendall     *float64 // tau-a, nil below two common posts
+}
+
+type Stability struct {
+	Polls []PollStability // from the second poll of the range
+
+	// averages over the polls
+	AvgEntered     *float64
+	AvgLeft        *float64
+	AvgTenureHours *float64
+	AvgSpearman    *float64
+	AvgKendall     *float64
+}
+
+type poll struct {
+	bucket time.Time
+	ranks  map[int64]int32
+	order  []int64 // by rank
+}
+
+// Stability measures how much the top n of the scope changes from one poll to the next.
+func (s Service) Stability(scope Scope, topN int32) (Stability, error) {
+	if err := scope.validate(); err != nil {
+		return Stability{}, err
+	}
+	if topN < 1 {
+		return Stability{}, fmt.Errorf("top n %d not supported, need at least 1", topN)
+	}
+
+	n, err := s.repo.CountPolls(scope.SubredditName, scope.OrderType, scope.Past, scope.From, scope.To)
+	if err != nil {
+		return Stability{}, err
+	}
+	if n > MaxStabilityPolls {
+		return Stability{}, fmt.Errorf("%d polls in range, more than %d, use a shorter range", n, MaxStabilityPolls)
+	}
+
+	pollRanks, err := s.repo.GetPollRanks(scope.SubredditName, scope.OrderType, scope.Past, scope.From, scope.To)
+	if err != nil {
+		return Stability{}, err
+	}
+
+	var polls []poll
+	for _, pr := range pollRanks {
+		if len(polls) == 0 || !polls[len(polls)-1].bucket.Equal(pr.Bucket) {
+			polls = append(polls, poll{bucket: pr.Bucket, ranks: make(map[int64]int32)})
+		}
+		p := &polls[len(polls)-1]
+		p.ranks[pr.PostId] = pr.Rank
+		p.order = append(p.order, pr.PostId)
+	}
+
+	inTopN := func(p poll, postId int64) bool {
+		rank, ok := p.ranks[postId]
+		return ok && rank <= topN
+	}
+
+	var stability Stability
+	var sumEntered, sumLeft, sumTenure, sumSpearman, sumKendall float64
+	var nTenure, nSpearman, nKendall int
+	since := make(map[int64]time.Time) // when each post of the top n entered it
+	for i, cur := range polls {
+		for postId := range since {
+			if !inTopN(cur, postId) {
+				delete(since, postId)
+			}
+		}
+		for _, postId := range cur.order {
+			if _, ok := since[postId]; !ok && inTopN(cur, postId) {
+				since[postId] = cur.bucket
+			}
+		}
+		if i == 0 {
+			continue
+		}
+		prev := polls[i-1]
+
+		ps := PollStability{Bucket: cur.bucket}
+		for _, postId := range cur.order {
+			if inTopN(cur, postId) && !inTopN(prev, postId) {
+				ps.Entered++
+			}
+		}
+		for _, postId := range prev.order {
+			if inTopN(prev, postId) && !inTopN(cur, postId) {
+				ps.Left++
+			}
+		}
+
+		var tenure float64
+		var members int
+		for _, entered := range since {
+			tenure += cur.bucket.Sub(entered).Hours()
+			members++
+		}
+		if members > 0 {
+			avg := tenure / float64(members)
+			ps.AvgTenureHours = &avg
+			sumTenure += avg
+			nTenure++
+		}
+
+		var prevRanks, curRanks []float64
+		for _, postId := range prev.order {
+			if rank, ok := cur.ranks[postId]; ok {
+				prevRanks = append(prevRanks, float64(prev.ranks[postId]))
+				curRanks = append(curRanks, float64(rank))
+			}
+		}
+		ps.CommonPosts = len(prevRanks)
+		ps.Spearman = spearman(prevRanks, curRanks)
+		ps.Kendall = kendall(prevRanks, curRanks)
+		if ps.Spearman != nil {
+			sumSpearman += *ps.Spearman
+			nSpearman++
+		}
+		if ps.Kendall != nil {
+			sumKendall += *ps.Kendall
+			nKendall++
+		}
+
+		sumEntered += float64(ps.Entered)
+		sumLeft += float64(ps.Left)
+		stability.Polls = append(stability.Polls, ps)
+	}
+
+	stability.AvgEntered = average(sumEntered, len(stability.Polls))
+	stability.AvgLeft = average(sumLeft, len(stability.Polls))
+	stability.AvgTenureHours = average(sumTenure, nTenure)
+	stability.AvgSpearman = average(sumSpearman, nSpearman)
+	stability.AvgKendall = average(sumKendall, nKendall)
+	return stability, nil
+}
+
+func average(sum float64, n int) *float64 {
+	if n == 0 {
+		return nil
+	}
+	avg := sum / float64(n)
+	return &avg
+}
+
+// spearman is the rank correlation of the paired values, ranked among themselves. Values are distinct.
+func spearman(a, b []float64) *float64 {
+	n := len(a)
+	if n < 2 {
+		return nil
+	}
+	ra, rb := ranksOf(a), ranksOf(b)
+	var d2 float64
+	for i := range ra {
+		d := ra[i] - rb[i]
+		d2 += d * d
+	}
+	rho := 1 - 6*d2/float64(n*(n*n-1))
+	return &rho
+}
+
+// ranksOf gives each value its 1-based position among the values.
+func ranksOf(v []float64) []float64 {
+	ranks := make([]float64, len(v))
+	for i := range v {
+		r := 1
+		for j := range v {
+			if v[j] < v[i] {
+				r++
+			}
+		}
+		ranks[i] = float64(r)
+	}
+	return ranks
+}
+
+// kendall is tau-a of the paired values.
+func kendall(a, b []float64) *float64 {
+	n := len(a)
+	if n < 2 {
+		return nil
+	}
+	var concordant, discordant int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			s := (a[i] - a[j]) * (b[i] - b[j])
+			switch {
+			case s > 0:
+				concordant++
+			case s < 0:
+				discordant++
+			}
+		}
+	}
+	tau := float64(concordant-discordant) / float64(n*(n-1)/2)
+	return &tau
+}
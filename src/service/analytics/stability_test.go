@@ -0,0 +1,58 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRankCorrelation(t *testing.T) {
+	tests := []struct {
+		name              string
+		a, b              []float64
+		spearman, kendall *float64
+	}{
+		{"too few pairs", []float64{1}, []float64{1}, nil, nil},
+		{"same order", []float64{1, 2, 3, 4}, []float64{10, 20, 30, 40}, f(1), f(1)},
+		{"reversed", []float64{1, 2, 3, 4}, []float64{4, 3, 2, 1}, f(-1), f(-1)},
+		{"one swap", []float64{1, 2, 3, 4}, []float64{1, 3, 2, 4}, f(0.8), f(4.0 / 6)},
+		{"ranked among themselves", []float64{3, 7, 50}, []float64{1, 2, 3}, f(1), f(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spearman(tt.a, tt.b); !near(got, tt.spearman) {
+				t.Errorf("spearman = %v, want %v", show(got), show(tt.spearman))
+			}
+			if got := kendall(tt.a, tt.b); !near(got, tt.kendall) {
+				t.Errorf("kendall = %v, want %v", show(got), show(tt.kendall))
+			}
+		})
+	}
+}
+
+func TestRanksOf(t *testing.T) {
+	got := ranksOf([]float64{30, 10, 20})
+	want := []float64{3, 1, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ranks = %v, want %v", got, want)
+		}
+	}
+}
+
+func f(v float64) *float64 {
+	return &v
+}
+
+func near(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return math.Abs(*a-*b) < 1e-9
+}
+
+func show(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
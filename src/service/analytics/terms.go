@@ -0,0 +1,237 @@
+package analytics
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	analyticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/analytics"
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+	statisticsservice "github.com/noellimx/redditminer/src/service/statistics"
+	"github.com/noellimx/redditminer/src/utils/text"
+)
+
+// TermWeight is what a post adds to each term of its title in a bucket.
+type TermWeight string
+
+const (
+	TermWeightCount TermWeight = "count" // 1
+	TermWeightRank  TermWeight = "rank"  // 1 / best rank in the bucket
+	TermWeightScore TermWeight = "score" // peak score in the bucket, negative as 0
+)
+
+const (
+	MaxTermsNGram   = 3
+	MaxTermsLimit   = 1000
+	MaxTermsBuckets = 5000
+
+	// A term trends when its share of the weight in the latest bucket is MinTrendingLift times its average share
+	// in the earlier buckets, or it is new, and at least MinTrendingPosts posts carry it.
+	MinTrendingLift  = 2.0
+	MinTrendingPosts = 2
+)
+
+func (w TermWeight) of(t analyticsrepo.BucketTitle) float64 {
+	switch w {
+	case TermWeightRank:
+		return 1 / float64(t.BestRank)
+	case TermWeightScore:
+		if t.PeakScore == nil || *t.PeakScore < 0 {
+			return 0
+		}
+		return float64(*t.PeakScore)
+	default:
+		return 1
+	}
+}
+
+// TermSeries is the weight of one term per bucket, aligned with Terms.Buckets.
+type TermSeries struct {
+	Term    string
+	N       int // words in the term
+	Posts   int // distinct posts carrying the term over the range
+	Weight  float64
+	Weights []float64
+	Shares  []*float64 // weight over the weight of every term of the bucket, nil for empty buckets
+}
+
+type TrendingTerm struct {
+	Term          string
+	Posts         int // posts carrying the term in the latest bucket
+	Share         float64
+	BaselineShare float64  // average share over the earlier buckets with posts
+	Lift          *float64 // Share over BaselineShare, nil for terms new in the latest bucket
+}
+
+type Terms struct {
+	Buckets  []time.Time
+	Latest   *time.Time     // latest bucket with posts, the one trending terms are detected in
+	Terms    []TermSeries   // heaviest first
+	Trending []TrendingTerm // largest share gain first
+}
+
+// Terms tokenizes the titles of the posts in the top n of the scope per bucket of the granularity
+// and weighs their n-grams of up to maxN words, returning the limit heaviest terms and the limit most trending ones.
+func (s Service) Terms(scope Scope, granularity statisticsrepo.Granularity, topN int32, maxN int, weight TermWeight, limit int) (Terms, error) {
+	if err := scope.validate(); err != nil {
+		return Terms{}, err
+	}
+	step, ok := statisticsservice.GranularityToDuration[granularity]
+	if !ok {
+		return Terms{}, fmt.Errorf("granularity type not supported. =%d", granularity)
+	}
+	if topN < 1 {
+		return Terms{}, fmt.Errorf("top n %d not supported, need at least 1", topN)
+	}
+	if maxN < 1 || maxN > MaxTermsNGram {
+		return Terms{}, fmt.Errorf("n %d not supported, need 1 to %d", maxN, MaxTermsNGram)
+	}
+	if weight != TermWeightCount && weight != TermWeightRank && weight != TermWeightScore {
+		return Terms{}, fmt.Errorf("weight %s not supported, need count, rank or score", weight)
+	}
+	if limit < 1 || limit > MaxTermsLimit {
+		return Terms{}, fmt.Errorf("limit %d not supported, need 1 to %d", limit, MaxTermsLimit)
+	}
+
+	var buckets []time.Time
+	for t := scope.From.UTC().Truncate(step); t.Before(scope.To); t = t.Add(step) {
+		buckets = append(buckets, t)
+		if len(buckets) > MaxTermsBuckets {
+			return Terms{}, fmt.Errorf("more than %d buckets, use a coarser granularity or a shorter range", MaxTermsBuckets)
+		}
+	}
+	index := make(map[time.Time]int)
+	for i, t := range buckets {
+		index[t] = i
+	}
+
+	titles, err := s.repo.BucketTitles(scope.SubredditName, step, scope.OrderType, scope.Past, scope.From, scope.To, topN)
+	if err != nil {
+		return Terms{}, err
+	}
+
+	series := make(map[string]*termWeights)
+	bucketPosts := make([]map[string]int, len(buckets))
+	bucketWeight := make([]float64, len(buckets))
+	latest := -1
+	for _, t := range titles {
+		i, ok := index[t.Bucket.UTC()]
+		if !ok {
+			continue
+		}
+		latest = max(latest, i)
+		if bucketPosts[i] == nil {
+			bucketPosts[i] = make(map[string]int)
+		}
+		w := weight.of(t)
+		for _, term := range text.Terms(t.Title, maxN) {
+			tw, ok := series[term]
+			if !ok {
+				tw = &termWeights{term: term, weights: make(map[int]float64), posts: make(map[int64]struct{})}
+				series[term] = tw
+			}
+			tw.weight += w
+			tw.weights[i] += w
+			tw.posts[t.PostId] = struct{}{}
+			bucketPosts[i][term]++
+			bucketWeight[i] += w
+		}
+	}
+	share := func(i int, w float64) float64 {
+		if bucketWeight[i] > 0 {
+			return w / bucketWeight[i]
+		}
+		return 0
+	}
+
+	terms := Terms{Buckets: buckets}
+	all := make([]*termWeights, 0, len(series))
+	for _, tw := range series {
+		all = append(all, tw)
+	}
+	slices.SortFunc(all, func(a, b *termWeights) int {
+		if c := cmp.Compare(b.weight, a.weight); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.term, b.term)
+	})
+	// dense series only for the terms returned
+	for _, tw := range all[:min(limit, len(all))] {
+		ts := TermSeries{
+			Term:    tw.term,
+			N:       strings.Count(tw.term, " ") + 1,
+			Posts:   len(tw.posts),
+			Weight:  tw.weight,
+			Weights: make([]float64, len(buckets)),
+			Shares:  make([]*float64, len(buckets)),
+		}
+		for i := range buckets {
+			if bucketPosts[i] == nil {
+				continue
+			}
+			ts.Weights[i] = tw.weights[i]
+			sh := share(i, tw.weights[i])
+			ts.Shares[i] = &sh
+		}
+		terms.Terms = append(terms.Terms, ts)
+	}
+
+	if latest < 0 {
+		return terms, nil
+	}
+	terms.Latest = &buckets[latest]
+	var earlier int // buckets with posts before the latest
+	for i := 0; i < latest; i++ {
+		if bucketPosts[i] != nil {
+			earlier++
+		}
+	}
+	if earlier == 0 {
+		return terms, nil // nothing to trend against
+	}
+	for _, tw := range all {
+		if bucketPosts[latest][tw.term] < MinTrendingPosts {
+			continue
+		}
+		var baseline float64
+		for i, w := range tw.weights {
+			if i < latest {
+				baseline += share(i, w)
+			}
+		}
+		baseline /= float64(earlier)
+
+		tt := TrendingTerm{
+			Term:          tw.term,
+			Posts:         bucketPosts[latest][tw.term],
+			Share:         share(latest, tw.weights[latest]),
+			BaselineShare: baseline,
+		}
+		if baseline > 0 {
+			lift := tt.Share / baseline
+			if lift < MinTrendingLift {
+				continue
+			}
+			tt.Lift = &lift
+		}
+		terms.Trending = append(terms.Trending, tt)
+	}
+	slices.SortFunc(terms.Trending, func(a, b TrendingTerm) int {
+		if c := cmp.Compare(b.Share-b.BaselineShare, a.Share-a.BaselineShare); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Term, b.Term)
+	})
+	terms.Trending = terms.Trending[:min(limit, len(terms.Trending))]
+	return terms, nil
+}
+
+// termWeights is a term's weight per bucket index, holding only the buckets it appears in.
+type termWeights struct {
+	term    string
+	weight  float64
+	weights map[int]float64
+	posts   map[int64]struct{}
+}
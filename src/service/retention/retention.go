@@ -0,0 +1,144 @@
+package retention
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	retentionrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/retention"
+)
+
+// PartitionsAhead is how many months of partitions are kept created past the current one.
+const PartitionsAhead = 3
+
+// Policy is how long data of each granularity is kept. Zero keeps it forever.
+type Policy struct {
+	Raw     time.Duration // post_observations, serving minute and quarter hour granularities
+	Hourly  time.Duration
+	Daily   time.Duration
+	Archive bool // move expired data to the archive schema instead of dropping it
+}
+
+type Service struct {
+	repo      *retentionrepo.Repo
+	policy    Policy
+	replicaId string
+}
+
+func New(repo *retentionrepo.Repo, policy Policy, replicaId string) *Service {
+	return &Service{
+		repo:      repo,
+		policy:    policy,
+		replicaId: replicaId,
+	}
+}
+
+func (s Service) Policy() Policy {
+	return s.policy
+}
+
+// Run creates the upcoming partitions and applies the policy, logging and recording every action.
+// It does nothing when another replica is running it.
+func (s Service) Run() ([]retentionrepo.Action, error) {
+	lock, err := s.repo.TryLock()
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		log.Println("retention held by another replica, skipping")
+		return nil, nil
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("retention unlock error=%v\n", err)
+		}
+	}()
+
+	now := time.Now().UTC()
+	var actions []retentionrepo.Action
+	record := func(action retentionrepo.ActionType, target string, rows *int64) {
+		a := retentionrepo.Action{RanAt: now, ReplicaId: s.replicaId, Action: action, Target: target, Rows: rows}
+		log.Printf("retention action=%s target=%s\n", action, target)
+		if err := s.repo.InsertAction(a); err != nil {
+			log.Printf("retention record error=%v\n", err)
+		}
+		actions = append(actions, a)
+	}
+
+	for i := 0; i <= PartitionsAhead; i++ {
+		p := retentionrepo.MonthPartition(now.AddDate(0, i, 0))
+		created, err := s.repo.CreatePartition(p)
+		if err != nil {
+			return actions, fmt.Errorf("create partition %s: %w", p.Name, err)
+		}
+		if created {
+			record(retentionrepo.ActionCreatePartition, p.Name, nil)
+		}
+	}
+
+	if s.policy.Raw > 0 {
+		partitions, err := s.repo.GetPartitions()
+		if err != nil {
+			return actions, err
+		}
+		cutoff := now.Add(-s.policy.Raw)
+		for _, p := range partitions {
+			// only whole months are removed
+			if p.To.After(cutoff) {
+				continue
+			}
+			err := s.repo.DetachPartition(p, s.policy.Archive)
+			if err != nil {
+				return actions, fmt.Errorf("detach partition %s: %w", p.Name, err)
+			}
+			if s.policy.Archive {
+				record(retentionrepo.ActionArchivePartition, p.Name, nil)
+			} else {
+				record(retentionrepo.ActionDropPartition, p.Name, nil)
+			}
+		}
+	}
+
+	for table, ttl := range map[string]time.Duration{
+		retentionrepo.RollupTableHourly: s.policy.Hourly,
+		retentionrepo.RollupTableDaily:  s.policy.Daily,
+	} {
+		if ttl <= 0 {
+			continue
+		}
+		rows, err := s.repo.DeleteRollupBefore(table, now.Add(-ttl), s.policy.Archive)
+		if err != nil {
+			return actions, fmt.Errorf("expire %s: %w", table, err)
+		}
+		if rows == 0 {
+			continue
+		}
+		if s.policy.Archive {
+			record(retentionrepo.ActionArchiveRows, table, &rows)
+		} else {
+			record(retentionrepo.ActionDeleteRows, table, &rows)
+		}
+	}
+	return actions, nil
+}
+
+type Report struct {
+	Partitions []retentionrepo.Partition
+	Actions    []retentionrepo.Action
+}
+
+// Report lists the attached partitions and the latest actions.
+func (s Service) Report(limit int) (Report, error) {
+	partitions, err := s.repo.GetPartitions()
+	if err != nil {
+		return Report{}, err
+	}
+	actions, err := s.repo.GetActions(limit)
+	if err != nil {
+		return Report{}, err
+	}
+	return Report{
+		Partitions: partitions,
+		Actions:    actions,
+	}, nil
+}
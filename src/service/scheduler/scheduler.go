@@ -0,0 +1,237 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/noellimx/redditminer/src/infrastructure/reddit_miner"
+	schedulerrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/scheduler"
+	taskrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/task"
+	statisticsservice "github.com/noellimx/redditminer/src/service/statistics"
+	taskservice "github.com/noellimx/redditminer/src/service/task"
+	trendingservice "github.com/noellimx/redditminer/src/service/trending"
+)
+
+type Service struct {
+	repo              *schedulerrepo.Repo
+	taskService       *taskservice.Service
+	statisticsService *statisticsservice.Service
+	trendingService   *trendingservice.Service
+	replicaId         string
+	snapshotDir       string
+	runTimeout        time.Duration // runs still running after this long are expired
+}
+
+func New(repo *schedulerrepo.Repo, taskService *taskservice.Service, statisticsService *statisticsservice.Service, trendingService *trendingservice.Service, replicaId string, snapshotDir string, runTimeout time.Duration) *Service {
+	return &Service{
+		repo:              repo,
+		taskService:       taskService,
+		statisticsService: statisticsService,
+		trendingService:   trendingService,
+		replicaId:         replicaId,
+		snapshotDir:       snapshotDir,
+		runTimeout:        runTimeout,
+	}
+}
+
+func (s Service) ReplicaId() string {
+	return s.replicaId
+}
+
+// RunDue runs the tasks of the interval for the scheduled window.
+// Every replica may call it for the same window; each task runs once across the cluster.
+func (s Service) RunDue(interval taskrepo.Granularity, window time.Time) {
+	tasks, err := s.taskService.GetTasksByInterval(interval)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, task := range tasks {
+		go s.run(task, window)
+	}
+	log.Printf("Tasks: %#v\n", tasks)
+}
+
+func (s Service) run(task taskrepo.Task, window time.Time) {
+	if task.StartsAt != nil && window.Before(*task.StartsAt) {
+		return
+	}
+	if s.completeIfLimitReached(task, window) {
+		return
+	}
+
+	lock, err := s.repo.TryLock(task.Id)
+	if err != nil {
+		log.Printf("run task=%d window=%s lock error=%v\n", task.Id, window, err)
+		return
+	}
+	if lock == nil {
+		log.Printf("run task=%d window=%s held by another replica, skipping\n", task.Id, window)
+		return
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("run task=%d window=%s unlock error=%v\n", task.Id, window, err)
+		}
+	}()
+
+	runId, claimed, err := s.repo.ClaimRun(task.Id, window, s.replicaId)
+	if err != nil {
+		log.Printf("run task=%d window=%s claim error=%v\n", task.Id, window, err)
+		return
+	}
+	if !claimed {
+		log.Printf("run task=%d window=%s already ran, skipping\n", task.Id, window)
+		return
+	}
+
+	scrapeErr := s.statisticsService.Scrape(task.SubRedditName, reddit_miner.CreatedWithinPast(task.PostsCreatedWithinPast), reddit_miner.OrderByAlgo(task.OrderBy), taskservice.ToScraperOptions(task, s.snapshotDir), &runId)
+	if scrapeErr != nil {
+		log.Printf("run task=%d window=%s scrape error=%v\n", task.Id, window, scrapeErr)
+		err = s.repo.FinishRun(runId, schedulerrepo.RunStatusFailed, scrapeErr)
+		if err != nil {
+			log.Printf("run task=%d window=%s finish error=%v\n", task.Id, window, err)
+		}
+		return
+	}
+
+	err = s.repo.FinishRun(runId, schedulerrepo.RunStatusSucceeded, nil)
+	if err != nil {
+		log.Printf("run task=%d window=%s finish error=%v\n", task.Id, window, err)
+		return
+	}
+
+	_, err = s.trendingService.Detect(task.SubRedditName, string(task.OrderBy), string(task.PostsCreatedWithinPast))
+	if err != nil {
+		log.Printf("run task=%d window=%s trending error=%v\n", task.Id, window, err)
+	}
+	s.completeIfLimitReached(task, window)
+}
+
+// completeIfLimitReached moves the task to the completed status once it is past its end or out of runs.
+func (s Service) completeIfLimitReached(task taskrepo.Task, window time.Time) bool {
+	reached, err := s.taskService.LimitReached(task, window)
+	if err != nil {
+		log.Printf("run task=%d window=%s limit error=%v\n", task.Id, window, err)
+		return false
+	}
+	if !reached {
+		return false
+	}
+
+	log.Printf("run task=%d window=%s limit reached, completing\n", task.Id, window)
+	if err := s.taskService.Complete(task.Id); err != nil {
+		log.Printf("run task=%d window=%s complete error=%v\n", task.Id, window, err)
+	}
+	return true
+}
+
+// WarnMissed logs a missed-run warning for every window within the lookback that a task of the interval did not run.
+// One replica warns at a time; the others skip and return no windows.
+func (s Service) WarnMissed(interval taskrepo.Granularity, lookback time.Duration) ([]taskrepo.MissedWindow, error) {
+	lock, err := s.repo.TryLockMissedRuns()
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		log.Printf("warn missed interval=%s held by another replica, skipping\n", interval)
+		return nil, nil
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("warn missed interval=%s unlock error=%v\n", interval, err)
+		}
+	}()
+
+	missed, err := s.taskService.GetMissedWindows(interval, lookback)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range missed {
+		log.Printf("WARNING missed run task=%d window=%s\n", m.TaskId, m.WindowStart)
+	}
+	return missed, nil
+}
+
+// CatchUp applies each task's catch up policy to the windows it missed within the lookback.
+// Listings cannot be fetched retroactively, so a catch up run polls the current listing.
+// The once policy runs once, recorded against the latest missed window. The all policy runs once per missed window,
+// oldest first and one after the other, each recorded against its window.
+func (s Service) CatchUp(interval taskrepo.Granularity, lookback time.Duration) {
+	missed, err := s.taskService.GetMissedWindows(interval, lookback)
+	if err != nil {
+		log.Printf("catch up error=%v\n", err)
+		return
+	}
+	if len(missed) == 0 {
+		return
+	}
+
+	tasks, err := s.taskService.GetTasksByInterval(interval)
+	if err != nil {
+		log.Printf("catch up error=%v\n", err)
+		return
+	}
+
+	windowsByTask := make(map[int64][]time.Time)
+	for _, m := range missed {
+		windowsByTask[m.TaskId] = append(windowsByTask[m.TaskId], m.WindowStart)
+	}
+
+	for _, task := range tasks {
+		windows := windowsByTask[task.Id]
+		if len(windows) == 0 {
+			continue
+		}
+
+		log.Printf("catch up task=%d policy=%s missed=%d\n", task.Id, task.CatchUpPolicy, len(windows))
+		switch task.CatchUpPolicy {
+		case taskrepo.CatchUpPolicyOnce:
+			go s.run(task, windows[len(windows)-1])
+		case taskrepo.CatchUpPolicyAll:
+			go func() {
+				for _, window := range windows {
+					s.run(task, window)
+				}
+			}()
+		}
+	}
+}
+
+// ExpireRuns marks the runs still running after the run timeout as failed, so that their windows can be claimed again.
+// These were left by a replica that stopped mid run.
+func (s Service) ExpireRuns() (int64, error) {
+	expired, err := s.repo.ExpireRuns(time.Now().UTC().Add(-s.runTimeout))
+	if err != nil {
+		return 0, err
+	}
+	if expired > 0 {
+		log.Printf("expired runs=%d timeout=%s\n", expired, s.runTimeout)
+	}
+	return expired, nil
+}
+
+type Holders struct {
+	Locks []schedulerrepo.LockHolder
+	Runs  []schedulerrepo.Run
+}
+
+// Holders reports the task locks held across the cluster and the runs in progress.
+func (s Service) Holders() (Holders, error) {
+	locks, err := s.repo.GetLockHolders()
+	if err != nil {
+		return Holders{}, err
+	}
+
+	runs, err := s.repo.GetRunsByStatus(schedulerrepo.RunStatusRunning)
+	if err != nil {
+		return Holders{}, err
+	}
+
+	return Holders{
+		Locks: locks,
+		Runs:  runs,
+	}, nil
+}
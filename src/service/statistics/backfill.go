@@ -0,0 +1,191 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+)
+
+// BackfillStrategy fills the buckets of a query in which a post was not observed.
+type BackfillStrategy string
+
+const (
+	BackfillNone   BackfillStrategy = "none"   // observations only
+	BackfillNull   BackfillStrategy = "null"   // synthetic points without rank, score and comment count
+	BackfillLOCF   BackfillStrategy = "locf"   // last observation carried forward
+	BackfillLinear BackfillStrategy = "linear" // interpolated between the surrounding observations
+	BackfillDrop   BackfillStrategy = "drop"   // drop posts observed in fewer buckets than the minimum coverage, null for the rest
+)
+
+func (b BackfillStrategy) validate(minCoverage float64) error {
+	switch b {
+	case BackfillNone, BackfillNull, BackfillLOCF, BackfillLinear:
+		return nil
+	case BackfillDrop:
+		if minCoverage < 0 || minCoverage > 1 {
+			return fmt.Errorf("min coverage %v not supported, need 0 to 1", minCoverage)
+		}
+		return nil
+	}
+	return fmt.Errorf("backfill strategy %s not supported, need one of %s", b, strings.Join([]string{
+		string(BackfillNone), string(BackfillNull), string(BackfillLOCF), string(BackfillLinear), string(BackfillDrop),
+	}, ", "))
+}
+
+// grid is every tick between from and to, exclusive, plus the observed buckets.
+// A nil bound falls back to the first or the last observed bucket, inclusive.
+func grid(from, to *time.Time, tick time.Duration, buckets []statisticsrepo.BucketCount) []time.Time {
+	if len(buckets) == 0 && (from == nil || to == nil) {
+		return []time.Time{}
+	}
+	all := make(map[time.Time]struct{})
+	for _, b := range buckets {
+		all[b.Bucket] = struct{}{}
+	}
+	var start, end time.Time
+	if from != nil {
+		start = from.Truncate(tick)
+		if !start.After(*from) {
+			start = start.Add(tick)
+		}
+	} else {
+		start = buckets[0].Bucket
+	}
+	if to != nil {
+		end = to.Add(-1)
+	} else {
+		end = buckets[len(buckets)-1].Bucket
+	}
+	for t := start; !t.After(end); t = t.Add(tick) {
+		all[t] = struct{}{}
+	}
+	times := make([]time.Time, 0, len(all))
+	for t := range all {
+		times = append(times, t)
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	return times
+}
+
+// backfill orders the observations by bucket, rank and post, and fills in the gaps per the strategy.
+// Every post of heads gets a point in every bucket of times; observations of other posts are left out.
+// heads holds each post's earliest observation of the query, whose metadata and ranking context a synthetic
+// point carries along with the method it was filled with. before and after hold each post's last observation
+// before and first observation after the page, so that locf and linear fill across page edges.
+// Where locf or linear have no observation to fill from, the point is null.
+func backfill(postsDb []statisticsrepo.Post, times []time.Time, strategy BackfillStrategy, heads, before, after []statisticsrepo.Post) []Post {
+	posts := []Post{}
+
+	if strategy == BackfillNone {
+		for _, post := range postsDb {
+			posts = append(posts, cloneType(post))
+		}
+		sortPosts(posts)
+		return posts
+	}
+
+	// build series by ks_id
+	series := make(map[ /*ks_id*/ string][]statisticsrepo.Post)
+	for _, post := range postsDb {
+		series[post.DataKsId] = append(series[post.DataKsId], post)
+	}
+	byId := func(posts []statisticsrepo.Post) map[string]*statisticsrepo.Post {
+		m := make(map[string]*statisticsrepo.Post, len(posts))
+		for i := range posts {
+			m[posts[i].DataKsId] = &posts[i]
+		}
+		return m
+	}
+	lastBefore, firstAfter := byId(before), byId(after)
+
+	for _, first := range heads {
+		observed := series[first.DataKsId]
+		slices.SortFunc(observed, func(a, b statisticsrepo.Post) int {
+			return a.PolledTimeRoundedMinute.Compare(b.PolledTimeRoundedMinute)
+		})
+
+		next := 0 // index in observed of the first observation at or after the bucket
+		for _, t := range times {
+			if next < len(observed) && observed[next].PolledTimeRoundedMinute.Equal(t) {
+				posts = append(posts, cloneType(observed[next]))
+				next++
+				continue
+			}
+
+			p := Post{
+				Title:         first.Title,
+				PermaLinkPath: first.PermaLinkPath,
+				DataKsId:      first.DataKsId,
+				SubredditId:   first.SubredditId,
+				SubredditName: first.SubredditName,
+				AuthorId:      first.AuthorId,
+				AuthorName:    first.AuthorName,
+
+				PolledTime:              t,
+				PolledTimeRoundedMinute: t,
+
+				RankOrderType:                 first.RankOrderType,
+				RankOrderForCreatedWithinPast: first.RankOrderForCreatedWithinPast,
+				PostCreatedAt:                 first.PostCreatedAt,
+
+				IsSynthetic:    true,
+				BackfillMethod: BackfillNull,
+			}
+
+			prev, succ := lastBefore[first.DataKsId], firstAfter[first.DataKsId]
+			if next > 0 {
+				prev = &observed[next-1]
+			}
+			if next < len(observed) {
+				succ = &observed[next]
+			}
+			switch {
+			case strategy == BackfillLOCF && prev != nil:
+				p.Rank, p.Score, p.CommentCount = ptr(prev.Rank), ptr(prev.Score), ptr(prev.CommentCount)
+				p.BackfillMethod = BackfillLOCF
+			case strategy == BackfillLinear && prev != nil && succ != nil:
+				f := float64(t.Sub(prev.PolledTimeRoundedMinute)) / float64(succ.PolledTimeRoundedMinute.Sub(prev.PolledTimeRoundedMinute))
+				p.Rank = ptr(lerp(prev.Rank, succ.Rank, f))
+				p.Score = ptr(lerp(prev.Score, succ.Score, f))
+				p.CommentCount = ptr(lerp(prev.CommentCount, succ.CommentCount, f))
+				p.BackfillMethod = BackfillLinear
+			}
+			posts = append(posts, p)
+		}
+	}
+
+	sortPosts(posts)
+	return posts
+}
+
+func ptr(v int32) *int32 {
+	return &v
+}
+
+func lerp(a, b int32, f float64) int32 {
+	return int32(math.Round(float64(a) + f*float64(b-a)))
+}
+
+// sortPosts orders by bucket, then rank with null ranks last, then post.
+func sortPosts(posts []Post) {
+	slices.SortFunc(posts, func(a, b Post) int {
+		if c := a.PolledTimeRoundedMinute.Compare(b.PolledTimeRoundedMinute); c != 0 {
+			return c
+		}
+		switch {
+		case a.Rank != nil && b.Rank != nil:
+			if *a.Rank != *b.Rank {
+				return int(*a.Rank - *b.Rank)
+			}
+		case a.Rank != nil:
+			return -1
+		case b.Rank != nil:
+			return 1
+		}
+		return strings.Compare(a.DataKsId, b.DataKsId)
+	})
+}
@@ -0,0 +1,161 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+)
+
+func TestGrid(t *testing.T) {
+	from, to := at(0).Add(30*time.Second), at(4)
+	tests := []struct {
+		name     string
+		from, to *time.Time
+		buckets  []statisticsrepo.BucketCount
+		want     []time.Time
+	}{
+		{"bounds exclusive", &from, &to, nil, []time.Time{at(1), at(2), at(3)}},
+		{"observed off the ticks", &from, &to, []statisticsrepo.BucketCount{{Bucket: at(2).Add(30 * time.Second)}}, []time.Time{at(1), at(2), at(2).Add(30 * time.Second), at(3)}},
+		{"unbounded falls back to observed", nil, nil, []statisticsrepo.BucketCount{{Bucket: at(1)}, {Bucket: at(3)}}, []time.Time{at(1), at(2), at(3)}},
+		{"unbounded without observations", nil, nil, nil, []time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := grid(tt.from, tt.to, time.Minute, tt.buckets)
+			if len(got) != len(tt.want) {
+				t.Fatalf("grid = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Fatalf("grid = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	times := []time.Time{at(1), at(2), at(3)}
+	a1 := observation("a", 1, 1, 100)
+	a3 := observation("a", 3, 3, 300)
+	a0 := observation("a", 0, 2, 40)  // before the page
+	a5 := observation("a", 5, 5, 500) // after the page
+
+	type point struct {
+		rank      *int32
+		score     *int32
+		synthetic bool
+		method    BackfillStrategy
+	}
+	r := func(v int32) *int32 { return &v }
+
+	tests := []struct {
+		name          string
+		strategy      BackfillStrategy
+		rows          []statisticsrepo.Post
+		before, after []statisticsrepo.Post
+		want          []point
+	}{
+		{
+			name:     "none keeps observations",
+			strategy: BackfillNone,
+			rows:     []statisticsrepo.Post{a3, a1},
+			want:     []point{{rank: r(1), score: r(100)}, {rank: r(3), score: r(300)}},
+		},
+		{
+			name:     "null",
+			strategy: BackfillNull,
+			rows:     []statisticsrepo.Post{a1, a3},
+			want:     []point{{rank: r(1), score: r(100)}, {synthetic: true, method: BackfillNull}, {rank: r(3), score: r(300)}},
+		},
+		{
+			name:     "locf",
+			strategy: BackfillLOCF,
+			rows:     []statisticsrepo.Post{a1, a3},
+			want:     []point{{rank: r(1), score: r(100)}, {rank: r(1), score: r(100), synthetic: true, method: BackfillLOCF}, {rank: r(3), score: r(300)}},
+		},
+		{
+			name:     "linear",
+			strategy: BackfillLinear,
+			rows:     []statisticsrepo.Post{a1, a3},
+			want:     []point{{rank: r(1), score: r(100)}, {rank: r(2), score: r(200), synthetic: true, method: BackfillLinear}, {rank: r(3), score: r(300)}},
+		},
+		{
+			name:     "locf without an earlier observation is null",
+			strategy: BackfillLOCF,
+			rows:     []statisticsrepo.Post{a3},
+			want:     []point{{synthetic: true, method: BackfillNull}, {synthetic: true, method: BackfillNull}, {rank: r(3), score: r(300)}},
+		},
+		{
+			name:     "locf across the page edge",
+			strategy: BackfillLOCF,
+			rows:     []statisticsrepo.Post{a3},
+			before:   []statisticsrepo.Post{a0},
+			want:     []point{{rank: r(2), score: r(40), synthetic: true, method: BackfillLOCF}, {rank: r(2), score: r(40), synthetic: true, method: BackfillLOCF}, {rank: r(3), score: r(300)}},
+		},
+		{
+			name:     "linear across both page edges",
+			strategy: BackfillLinear,
+			before:   []statisticsrepo.Post{a0},
+			after:    []statisticsrepo.Post{a5},
+			want: []point{
+				{rank: r(3), score: r(132), synthetic: true, method: BackfillLinear},
+				{rank: r(3), score: r(224), synthetic: true, method: BackfillLinear},
+				{rank: r(4), score: r(316), synthetic: true, method: BackfillLinear},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heads := []statisticsrepo.Post{a1}
+			got := backfill(tt.rows, times, tt.strategy, heads, tt.before, tt.after)
+			if len(got) != len(tt.want) {
+				t.Fatalf("points = %d, want %d", len(got), len(tt.want))
+			}
+			for i, w := range tt.want {
+				p := got[i]
+				if !equalInt(p.Rank, w.rank) || !equalInt(p.Score, w.score) || p.IsSynthetic != w.synthetic || p.BackfillMethod != w.method {
+					t.Errorf("point %d = rank %v score %v synthetic %v method %q, want rank %v score %v synthetic %v method %q",
+						i, deref(p.Rank), deref(p.Score), p.IsSynthetic, p.BackfillMethod, deref(w.rank), deref(w.score), w.synthetic, w.method)
+				}
+				if p.IsSynthetic && p.Title != a1.Title {
+					t.Errorf("point %d title = %q, want the head's %q", i, p.Title, a1.Title)
+				}
+			}
+		})
+	}
+}
+
+func TestBackfillLeavesOutPostsNotKept(t *testing.T) {
+	rows := []statisticsrepo.Post{observation("a", 1, 1, 10), observation("b", 1, 2, 20)}
+	heads := []statisticsrepo.Post{rows[0]}
+	got := backfill(rows, []time.Time{at(1), at(2)}, BackfillNull, heads, nil, nil)
+	if len(got) != 2 {
+		t.Fatalf("points = %d, want 2", len(got))
+	}
+	for _, p := range got {
+		if p.DataKsId != "a" {
+			t.Fatalf("point of %s, want only a", p.DataKsId)
+		}
+	}
+}
+
+func TestSortPosts(t *testing.T) {
+	r := func(v int32) *int32 { return &v }
+	posts := []Post{
+		{DataKsId: "c", PolledTimeRoundedMinute: at(1)},
+		{DataKsId: "b", PolledTimeRoundedMinute: at(1), Rank: r(2)},
+		{DataKsId: "a", PolledTimeRoundedMinute: at(2), Rank: r(1)},
+		{DataKsId: "d", PolledTimeRoundedMinute: at(1), Rank: r(1)},
+	}
+	sortPosts(posts)
+	var got string
+	for _, p := range posts {
+		got += p.DataKsId
+	}
+	if got != "dbca" {
+		t.Fatalf("order = %s, want dbca", got)
+	}
+}
@@ -0,0 +1,82 @@
+package statistics
+
+import (
+	"time"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+)
+
+// Derived are the fields computed from a post's previous observation in the same ranking context.
+// Deltas and velocities are nil for synthetic points and for the first observation of a post;
+// across synthetic points they are taken against the last observed point.
+type Derived struct {
+	ScoreDelta      *int32
+	CommentDelta    *int32
+	ScoreVelocity   *float64 // score per hour since the previous observation
+	CommentVelocity *float64 // comments per hour since the previous observation
+	RankDelta       *int32   // previous rank minus rank, positive when the post moved up
+
+	HoursSinceCreated *float64 // nil when the creation time is unknown
+	HoursInTopN       float64  // buckets observed at or above the top n rank, up to and including this one
+}
+
+type seriesKey struct {
+	dataKsId string
+	context  Context
+}
+
+// derive sets Derived on posts ordered by bucket. The series carry on from seeds, each post's last
+// observation before the first of posts, and seedsInTopN, the buckets before it spent in the top n
+// by data ks id, so that a page derives the same fields as the full history.
+func derive(posts []Post, tick time.Duration, topN int32, seeds []statisticsrepo.Post, seedsInTopN map[string]int64) {
+	lastObserved := make(map[seriesKey]*Post)
+	inTopN := make(map[seriesKey]time.Duration)
+	for _, seed := range seeds {
+		p := cloneType(seed)
+		key := seriesKey{dataKsId: p.DataKsId, context: Context{RankOrderType: p.RankOrderType, RankOrderForCreatedWithinPast: p.RankOrderForCreatedWithinPast}}
+		lastObserved[key] = &p
+		inTopN[key] = time.Duration(seedsInTopN[p.DataKsId]) * tick
+	}
+
+	for i := range posts {
+		p := &posts[i]
+		key := seriesKey{dataKsId: p.DataKsId, context: Context{RankOrderType: p.RankOrderType, RankOrderForCreatedWithinPast: p.RankOrderForCreatedWithinPast}}
+
+		d := &Derived{}
+		if p.PostCreatedAt != nil {
+			hours := p.PolledTimeRoundedMinute.Sub(*p.PostCreatedAt).Hours()
+			d.HoursSinceCreated = &hours
+		}
+
+		if !p.IsSynthetic {
+			if p.Rank != nil && *p.Rank <= topN {
+				inTopN[key] += tick
+			}
+
+			if prev, ok := lastObserved[key]; ok {
+				hours := p.PolledTime.Sub(prev.PolledTime).Hours()
+				d.ScoreDelta, d.ScoreVelocity = delta(prev.Score, p.Score, hours)
+				d.CommentDelta, d.CommentVelocity = delta(prev.CommentCount, p.CommentCount, hours)
+				if prev.Rank != nil && p.Rank != nil {
+					rankDelta := *prev.Rank - *p.Rank
+					d.RankDelta = &rankDelta
+				}
+			}
+			lastObserved[key] = p
+		}
+		d.HoursInTopN = inTopN[key].Hours()
+		p.Derived = d
+	}
+}
+
+func delta(prev *int32, cur *int32, hours float64) (*int32, *float64) {
+	if prev == nil || cur == nil {
+		return nil, nil
+	}
+	d := *cur - *prev
+	if hours <= 0 {
+		return &d, nil
+	}
+	velocity := float64(d) / hours
+	return &d, &velocity
+}
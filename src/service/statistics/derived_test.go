@@ -0,0 +1,90 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+)
+
+func TestDerive(t *testing.T) {
+	created := at(-60)
+	posts := []Post{
+		cloneType(observation("a", 0, 5, 100)),
+		{DataKsId: "a", PolledTime: at(30), PolledTimeRoundedMinute: at(30), IsSynthetic: true},
+		cloneType(observation("a", 60, 2, 160)),
+	}
+	for i := range posts {
+		posts[i].PostCreatedAt = &created
+	}
+
+	derive(posts, time.Hour, 3, nil, nil)
+
+	first, synthetic, last := posts[0].Derived, posts[1].Derived, posts[2].Derived
+	if first.ScoreDelta != nil || first.RankDelta != nil || first.ScoreVelocity != nil {
+		t.Errorf("first observation deltas = %v, %v, %v, want nil", first.ScoreDelta, first.RankDelta, first.ScoreVelocity)
+	}
+	if synthetic.ScoreDelta != nil || synthetic.RankDelta != nil {
+		t.Errorf("synthetic deltas = %v, %v, want nil", synthetic.ScoreDelta, synthetic.RankDelta)
+	}
+	if last.ScoreDelta == nil || *last.ScoreDelta != 60 {
+		t.Errorf("score delta = %v, want 60", deref(last.ScoreDelta))
+	}
+	if last.ScoreVelocity == nil || *last.ScoreVelocity != 60 {
+		t.Errorf("score velocity = %v, want 60 per hour", last.ScoreVelocity)
+	}
+	if last.RankDelta == nil || *last.RankDelta != 3 {
+		t.Errorf("rank delta = %v, want 3", deref(last.RankDelta))
+	}
+	if last.HoursSinceCreated == nil || *last.HoursSinceCreated != 2 {
+		t.Errorf("hours since created = %v, want 2", last.HoursSinceCreated)
+	}
+	if first.HoursInTopN != 0 || last.HoursInTopN != 1 {
+		t.Errorf("hours in top n = %v, %v, want 0 and 1", first.HoursInTopN, last.HoursInTopN)
+	}
+}
+
+func TestDeriveCarriesOnFromSeeds(t *testing.T) {
+	posts := []Post{cloneType(observation("a", 60, 1, 150))}
+	seeds := []statisticsrepo.Post{observation("a", 0, 4, 100)}
+
+	derive(posts, time.Hour, 3, seeds, map[string]int64{"a": 5})
+
+	d := posts[0].Derived
+	if d.ScoreDelta == nil || *d.ScoreDelta != 50 {
+		t.Errorf("score delta = %v, want 50 against the seed", deref(d.ScoreDelta))
+	}
+	if d.RankDelta == nil || *d.RankDelta != 3 {
+		t.Errorf("rank delta = %v, want 3 against the seed", deref(d.RankDelta))
+	}
+	if d.HoursInTopN != 6 {
+		t.Errorf("hours in top n = %v, want 5 seeded plus 1", d.HoursInTopN)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	v := func(i int32) *int32 { return &i }
+	tests := []struct {
+		name         string
+		prev, cur    *int32
+		hours        float64
+		wantDelta    *int32
+		wantVelocity *float64
+	}{
+		{"missing previous", nil, v(3), 1, nil, nil},
+		{"missing current", v(3), nil, 1, nil, nil},
+		{"no time elapsed", v(1), v(3), 0, v(2), nil},
+		{"per hour", v(10), v(4), 2, v(-6), func() *float64 { f := -3.0; return &f }()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, velocity := delta(tt.prev, tt.cur, tt.hours)
+			if !equalInt(d, tt.wantDelta) {
+				t.Errorf("delta = %v, want %v", deref(d), deref(tt.wantDelta))
+			}
+			if (velocity == nil) != (tt.wantVelocity == nil) || (velocity != nil && *velocity != *tt.wantVelocity) {
+				t.Errorf("velocity = %v, want %v", velocity, tt.wantVelocity)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package statistics
+
+import (
+	"fmt"
+	"time"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+)
+
+// Context is a ranking context a post was observed in.
+type Context struct {
+	RankOrderType                 statisticsrepo.OrderByAlgo
+	RankOrderForCreatedWithinPast statisticsrepo.CreatedWithinPast
+}
+
+// ContextSummary summarizes the observations of a post in one ranking context.
+type ContextSummary struct {
+	Context
+	Observations int
+	FirstSeenAt  time.Time
+	LastSeenAt   time.Time
+	PeakRank     int32
+	PeakRankAt   time.Time // first poll at the peak rank
+	PeakScore    *int32
+}
+
+type Series struct {
+	Post statisticsrepo.PostMeta
+
+	// over every ranking context
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+	PeakRank    *int32
+	PeakRankAt  *time.Time
+	PeakContext *Context
+
+	Contexts []ContextSummary
+	Points   []statisticsrepo.Observation
+}
+
+// PostSeries returns one post's observations across every ranking context it appeared in, with summaries.
+// It returns statisticsrepo.ErrPostNotFound for a post never observed.
+func (s Service) PostSeries(dataKsId string) (Series, error) {
+	if dataKsId == "" {
+		return Series{}, fmt.Errorf("empty data ks id")
+	}
+
+	post, err := s.repo.GetPost(dataKsId)
+	if err != nil {
+		return Series{}, err
+	}
+
+	points, err := s.repo.GetObservations(post.Id)
+	if err != nil {
+		return Series{}, err
+	}
+
+	series := Series{
+		Post:        post,
+		FirstSeenAt: post.FirstSeenAt,
+		LastSeenAt:  post.LastSeenAt,
+		Points:      points,
+	}
+
+	// points are ordered by poll, so the first point at a rank is the earliest
+	byContext := make(map[Context]int)
+	for _, p := range points {
+		c := Context{RankOrderType: p.RankOrderType, RankOrderForCreatedWithinPast: p.RankOrderForCreatedWithinPast}
+		i, ok := byContext[c]
+		if !ok {
+			i = len(series.Contexts)
+			byContext[c] = i
+			series.Contexts = append(series.Contexts, ContextSummary{
+				Context:     c,
+				FirstSeenAt: p.PolledTime,
+				PeakRank:    p.Rank,
+				PeakRankAt:  p.PolledTime,
+			})
+		}
+		summary := &series.Contexts[i]
+		summary.Observations++
+		summary.LastSeenAt = p.PolledTime
+		if p.Rank < summary.PeakRank {
+			summary.PeakRank = p.Rank
+			summary.PeakRankAt = p.PolledTime
+		}
+		if p.Score != nil && (summary.PeakScore == nil || *p.Score > *summary.PeakScore) {
+			score := *p.Score
+			summary.PeakScore = &score
+		}
+
+		if series.PeakRank == nil || p.Rank < *series.PeakRank {
+			rank, at := p.Rank, p.PolledTime
+			series.PeakRank, series.PeakRankAt, series.PeakContext = &rank, &at, &c
+		}
+	}
+	return series, nil
+}
@@ -1,6 +1,8 @@
 package statistics
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
 	"slices"
@@ -11,18 +13,28 @@ import (
 	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
 )
 
+// Watcher is shown the posts of every stored scrape.
+type Watcher interface {
+	Watch(posts []statisticsrepo.PostForm) error
+}
+
 type Service struct {
-	repo *statisticsrepo.Repo
+	repo           *statisticsrepo.Repo
+	conflictPolicy statisticsrepo.ConflictPolicy
+	watchers       []Watcher
 }
 
-func NewWWW(repo *statisticsrepo.Repo) *Service {
-	return &Service{repo: repo}
+func NewWWW(repo *statisticsrepo.Repo, conflictPolicy statisticsrepo.ConflictPolicy, watchers ...Watcher) *Service {
+	return &Service{repo: repo, conflictPolicy: conflictPolicy, watchers: watchers}
 }
 
-func (s Service) Scrape(subRedditName string, postsCreatedWithinPast reddit_miner.CreatedWithinPast, algo reddit_miner.OrderByAlgo) {
+// Scrape polls the listing once and stores every post of it, or none when an error is returned.
+// runId is the task run the poll is recorded against, nil outside a run.
+// The stored posts are then shown to the watchers, whose errors are logged.
+func (s Service) Scrape(subRedditName string, postsCreatedWithinPast reddit_miner.CreatedWithinPast, algo reddit_miner.OrderByAlgo, opts reddit_miner.Options, runId *int64) error {
 	now := time.Now().UTC()
 	roundDownTo5Mins := now.Truncate(1 * time.Minute)
-	postCh := reddit_miner.SubRedditPosts(subRedditName, postsCreatedWithinPast, algo, false)
+	postCh := reddit_miner.SubRedditPostsWithOptions(subRedditName, postsCreatedWithinPast, algo, opts)
 
 	var postForms []statisticsrepo.PostForm
 
@@ -47,13 +59,25 @@ func (s Service) Scrape(subRedditName string, postsCreatedWithinPast reddit_mine
 			RankOrderType:                 statisticsrepo.OrderByAlgo(p.RankOrderType),
 			RankOrderForCreatedWithinPast: statisticsrepo.CreatedWithinPast(p.RankOrderForCreatedWithinPast),
 			PostCreatedAt:                 ts,
+			RunId:                         runId,
 		})
 	}
 	//
 	//log.Printf("PostForms: %#v\n", len(postForms))
 	//log.Printf("Posts: %#v\n", len(posts))
 
-	s.repo.InsertMany(postForms)
+	if len(postForms) == 0 {
+		return fmt.Errorf("no posts scraped from subreddit %s order %s past %s", subRedditName, algo, postsCreatedWithinPast)
+	}
+	if err := s.repo.InsertMany(postForms, s.conflictPolicy); err != nil {
+		return err
+	}
+	for _, w := range s.watchers {
+		if err := w.Watch(postForms); err != nil {
+			log.Printf("watch subreddit=%s order=%s past=%s error=%v\n", subRedditName, algo, postsCreatedWithinPast, err)
+		}
+	}
+	return nil
 }
 
 type Post struct {
@@ -76,6 +100,18 @@ type Post struct {
 	RankOrderForCreatedWithinPast statisticsrepo.CreatedWithinPast
 	Rank                          *int32
 	IsSynthetic                   bool
+	BackfillMethod                BackfillStrategy // how a synthetic point was filled in, empty for observations
+	PostCreatedAt                 *time.Time
+
+	// Aggregates over the bucket for rollup granularities. Rank and Score are then the last observed.
+	MinRank  *int32
+	MaxRank  *int32
+	AvgRank  *float64
+	MinScore *int32
+	MaxScore *int32
+	AvgScore *float64
+
+	Derived *Derived // set when requested
 }
 
 func minTimeF(a, b time.Time) time.Time {
@@ -93,197 +129,317 @@ func maxTimeF(a, b time.Time) time.Time {
 }
 
 var GranularityToDuration = map[statisticsrepo.Granularity]time.Duration{
-	statisticsrepo.GranularityHour: time.Hour,
+	statisticsrepo.GranularityMinute:      time.Minute,
+	statisticsrepo.GranularityQuarterHour: 15 * time.Minute,
+	statisticsrepo.GranularityHour:        time.Hour,
+	statisticsrepo.GranularityDaily:       24 * time.Hour,
 }
 
-// Stats
-// We will cleanse the data here
-// - Dedupe
-// - Backfill (#data points = #post x #time)
-//   - incomplete series
-//   - missing series
-func (s Service) Stats(name string, orderType statisticsrepo.OrderByAlgo, past statisticsrepo.CreatedWithinPast, granularity statisticsrepo.Granularity, fromTime *time.Time, toTime *time.Time, shouldBackFill bool) ([]Post, error) {
-	if orderType != statisticsrepo.OrderByAlgoTop {
-		return []Post{}, fmt.Errorf("order algo type %s not supported", orderType)
+// Rollup recomputes the buckets of every rollup granularity holding observations stored since its previous run,
+// every bucket on the first run. A positive lookback also recomputes the buckets covering it, plus the previous bucket.
+// It is skipped while another replica runs it.
+func (s Service) Rollup(lookback time.Duration) error {
+	lock, err := s.repo.TryLockRollup()
+	if err != nil {
+		return err
 	}
-
-	if !(past == statisticsrepo.CreatedWithinPastDay || past == statisticsrepo.CreatedWithinPastWeek || past == statisticsrepo.CreatedWithinPastMonth) {
-		return []Post{}, fmt.Errorf("past day %s not supported", past)
+	if lock == nil {
+		log.Println("rollup held by another replica, skipping")
+		return nil
 	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("rollup unlock error=%v\n", err)
+		}
+	}()
 
-	if name == "" {
-		return []Post{}, fmt.Errorf("empty subreddit name")
+	now := time.Now().UTC()
+	for granularity, tick := range GranularityToDuration {
+		if !statisticsrepo.HasRollup(granularity) {
+			continue
+		}
+		if lookback > 0 {
+			rows, err := s.repo.Rollup(granularity, now.Add(-lookback-tick))
+			if err != nil {
+				return fmt.Errorf("rollup granularity %d: %w", granularity, err)
+			}
+			log.Printf("Rollup granularity %d lookback %s rows %d\n", granularity, lookback, rows)
+		}
+		rows, err := s.repo.RollupChanged(granularity)
+		if err != nil {
+			return fmt.Errorf("rollup granularity %d: %w", granularity, err)
+		}
+		log.Printf("Rollup granularity %d changed rows %d\n", granularity, rows)
 	}
+	return nil
+}
+
+const (
+	DefaultMaxRank  = 20
+	DefaultPageSize = 1000
+	MaxPageSize     = 10000
+	DefaultTopN     = 10
 
-	switch granularity {
-	case statisticsrepo.GranularityHour:
-		break
-	default:
-		return nil, fmt.Errorf("granularity type not supported. =%d", granularity)
+	MaxBackfillBuckets = 100000 // buckets of a backfilled query
+)
+
+// Page is one page of Stats. NextCursor is empty on the last page.
+type Page struct {
+	Posts      []Post
+	Total      int64 // rows across every page
+	NextCursor string
+}
+
+// EncodeCursor makes the repo cursor opaque to clients.
+func EncodeCursor(c *statisticsrepo.Cursor) string {
+	if c == nil {
+		return ""
 	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
 
-	postsDb, err := s.repo.Stats(name, orderType, fromTime, toTime, past, granularity)
+func DecodeCursor(cursor string) (*statisticsrepo.Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		return []Post{}, err
+		return nil, fmt.Errorf("invalid cursor")
 	}
+	var c statisticsrepo.Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// StatsQuery selects a page of Stats.
+type StatsQuery struct {
+	Name        string
+	OrderType   statisticsrepo.OrderByAlgo
+	Past        statisticsrepo.CreatedWithinPast
+	Granularity statisticsrepo.Granularity
+	FromTime    *time.Time
+	ToTime      *time.Time
+	Backfill    BackfillStrategy
+	MinCoverage float64 // share of buckets a post must be observed in, for BackfillDrop
+
+	MinRank  int32
+	MaxRank  int32
+	Cursor   string // NextCursor of the previous page
+	PageSize int    // 0 for every row in a single page
+
+	Derived bool  // compute Post.Derived
+	TopN    int32 // rank counted as in the top for Derived.HoursInTopN
+}
 
-	if len(postsDb) == 0 {
-		return []Post{}, nil
+func (q StatsQuery) filter() statisticsrepo.StatsFilter {
+	return statisticsrepo.StatsFilter{
+		Name:        q.Name,
+		OrderType:   q.OrderType,
+		Past:        q.Past,
+		Granularity: q.Granularity,
+		FromTime:    q.FromTime,
+		ToTime:      q.ToTime,
+		MinRank:     q.MinRank,
+		MaxRank:     q.MaxRank,
 	}
+}
 
-	if !shouldBackFill {
-		var posts []Post
-		for _, post := range postsDb {
-			posts = append(posts, cloneType(post))
-		}
+// Stats returns a page of the posts ranked between MinRank and MaxRank, ordered by bucket, rank and post,
+// with the gaps of the page filled in per the backfill strategy.
+// A page holds whole buckets: as many as fit in PageSize rows, and at least one even when it alone holds more.
+// With backfill every bucket holds a row per post, see backfillPage.
+func (s Service) Stats(q StatsQuery) (Page, error) {
+	if q.OrderType != statisticsrepo.OrderByAlgoTop {
+		return Page{}, fmt.Errorf("order algo type %s not supported", q.OrderType)
+	}
 
-		slices.SortFunc(posts, func(a, b Post) int {
-			if a.PolledTimeRoundedMinute.Before(b.PolledTimeRoundedMinute) {
-				return -1
-			}
+	if !(q.Past == statisticsrepo.CreatedWithinPastDay || q.Past == statisticsrepo.CreatedWithinPastWeek || q.Past == statisticsrepo.CreatedWithinPastMonth) {
+		return Page{}, fmt.Errorf("past day %s not supported", q.Past)
+	}
 
-			if b.PolledTimeRoundedMinute.Before(a.PolledTimeRoundedMinute) {
-				return 1
-			}
-			if a.Rank != nil && b.Rank != nil {
-				if *a.Rank < *b.Rank {
-					return -1
-				}
-				if *b.Rank < *a.Rank {
-					return 1
-				}
-			} else {
-				log.Printf("?? why some nil")
-			}
-			return 0
-		})
-		return posts, nil
+	if q.Name == "" {
+		return Page{}, fmt.Errorf("empty subreddit name")
 	}
 
-	// cleansing..
-	tick := GranularityToDuration[granularity]
-	if tick == 0 {
-		return []Post{}, fmt.Errorf("unknown converstion from granularity type to tick. =%d", granularity)
+	if _, ok := GranularityToDuration[q.Granularity]; !ok {
+		return Page{}, fmt.Errorf("granularity type not supported. =%d", q.Granularity)
 	}
 
-	// build series by ks_id
-	twoD := make(map[ /*ks_id*/ string]map[ /*polled time rounded */ time.Time]statisticsrepo.Post)
+	if q.MinRank < 1 || q.MaxRank < q.MinRank {
+		return Page{}, fmt.Errorf("rank range %d to %d not supported, need 1 <= min_rank <= max_rank", q.MinRank, q.MaxRank)
+	}
 
-	allTimes := make(map[time.Time]struct{})
-	minTime, maxTime := postsDb[0].PolledTimeRoundedMinute, postsDb[0].PolledTimeRoundedMinute
+	if q.PageSize < 0 || q.PageSize > MaxPageSize {
+		return Page{}, fmt.Errorf("page size %d not supported, need 1 to %d, or 0 for every row", q.PageSize, MaxPageSize)
+	}
 
-	// dedup
-	for _, post := range postsDb {
-		// build 2D
-		m, ok := twoD[post.DataKsId]
-		if !ok {
-			m = make(map[time.Time]statisticsrepo.Post)
-		}
-		m[post.PolledTimeRoundedMinute] = post
-		twoD[post.DataKsId] = m
+	if err := q.Backfill.validate(q.MinCoverage); err != nil {
+		return Page{}, err
+	}
 
-		allTimes[post.PolledTimeRoundedMinute] = struct{}{}
-		minTime = minTimeF(post.PolledTimeRoundedMinute, minTime)
-		maxTime = maxTimeF(post.PolledTimeRoundedMinute, maxTime)
+	if q.Derived && q.TopN < 1 {
+		return Page{}, fmt.Errorf("top n %d not supported, need at least 1", q.TopN)
 	}
 
-	// backfill incomplete series
-	var posts []Post
-	for _, _posts := range twoD {
-		firstTime := time.Time{}
-		for k, _ := range _posts {
-			firstTime = k
-			break
-		}
-		firstPost := _posts[firstTime]
-		for needTime, _ := range allTimes {
-			p, ok := _posts[needTime]
-			var _p Post
-			if ok {
-				_p = cloneType(p)
-			} else {
-				_p = Post{
-					Title:         firstPost.Title,
-					PermaLinkPath: firstPost.PermaLinkPath,
-					DataKsId:      firstPost.DataKsId,
-					SubredditId:   firstPost.SubredditId,
-					Score:         nil,
-					CommentCount:  nil,
-					SubredditName: firstPost.SubredditName,
-					AuthorId:      firstPost.AuthorId,
-					AuthorName:    firstPost.AuthorName,
-
-					PolledTime:              needTime,
-					PolledTimeRoundedMinute: needTime,
-
-					Rank:                          nil,
-					RankOrderType:                 firstPost.RankOrderType,
-					RankOrderForCreatedWithinPast: firstPost.RankOrderForCreatedWithinPast,
-					IsSynthetic:                   true,
-				}
-			}
-			posts = append(posts, _p)
+	cursor, err := DecodeCursor(q.Cursor)
+	if err != nil {
+		return Page{}, err
+	}
+	var after *time.Time
+	if cursor != nil {
+		after = &cursor.Bucket
+	}
+
+	f := q.filter()
+	buckets, err := s.repo.StatsBuckets(f)
+	if err != nil {
+		return Page{}, err
+	}
+
+	if q.Backfill != BackfillNone {
+		return s.backfillPage(q, f, buckets, after)
+	}
+
+	var page Page
+	for _, b := range buckets {
+		page.Total += b.Rows
+	}
+
+	i := 0 // first bucket of the page
+	for i < len(buckets) && after != nil && !buckets[i].Bucket.After(*after) {
+		i++
+	}
+	j, rows := i, int64(0) // past the last bucket of the page
+	for j < len(buckets) && (q.PageSize == 0 || j == i || rows+buckets[j].Rows <= int64(q.PageSize)) {
+		rows += buckets[j].Rows
+		j++
+	}
+	if i == j {
+		page.Posts = []Post{}
+		return page, nil
+	}
+	until := buckets[j-1].Bucket
+	if j < len(buckets) {
+		page.NextCursor = EncodeCursor(&statisticsrepo.Cursor{Bucket: until})
+	}
+
+	postsDb, err := s.repo.Stats(f, after, &until)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page.Posts = backfill(postsDb, nil, q.Backfill, nil, nil, nil)
+	if q.Derived {
+		if err := s.derive(q, buckets[i].Bucket, page.Posts); err != nil {
+			return Page{}, err
 		}
 	}
+	return page, nil
+}
+
+// backfillPage pages a backfilled query. The buckets are every granularity step between FromTime and ToTime,
+// or the first and last observed bucket when unset, and each holds a row for every post kept by the strategy.
+// Coverage for BackfillDrop is counted over every bucket of the query. A page holds PageSize / posts buckets,
+// and at least one.
+func (s Service) backfillPage(q StatsQuery, f statisticsrepo.StatsFilter, buckets []statisticsrepo.BucketCount, after *time.Time) (Page, error) {
+	times := grid(q.FromTime, q.ToTime, GranularityToDuration[q.Granularity], buckets)
+	if len(times) > MaxBackfillBuckets {
+		return Page{}, fmt.Errorf("backfill over %d buckets not supported, need at most %d: narrow from_time and to_time or use a coarser granularity", len(times), MaxBackfillBuckets)
+	}
 
-	// backfill missing series
-	for t := minTime; t.Before(maxTime); t = t.Add(tick) {
-		_, inAllTimes := allTimes[t]
-		if inAllTimes {
+	observed, err := s.repo.StatsObserved(f)
+	if err != nil {
+		return Page{}, err
+	}
+	var kept []string
+	for dataKsId, n := range observed {
+		if q.Backfill == BackfillDrop && float64(n) < q.MinCoverage*float64(len(times)) {
 			continue
 		}
+		kept = append(kept, dataKsId)
+	}
+	slices.Sort(kept)
 
-		for ksId, _posts := range twoD {
-			firstTime := time.Time{}
-			for k, _ := range _posts {
-				firstTime = k
-				break
-			}
-			firstPost := _posts[firstTime]
-
-			posts = append(posts, Post{
-				Title:       firstPost.Title,
-				DataKsId:    ksId,
-				IsSynthetic: true,
-
-				PermaLinkPath: firstPost.PermaLinkPath,
-				SubredditId:   "",
-				SubredditName: "",
-				AuthorId:      "",
-				AuthorName:    "",
-
-				PolledTimeRoundedMinute: t,
-
-				Score:                         nil,
-				CommentCount:                  nil,
-				RankOrderType:                 "",
-				RankOrderForCreatedWithinPast: "",
-				Rank:                          nil,
-			})
-		}
+	page := Page{Posts: []Post{}, Total: int64(len(kept) * len(times))}
+	if len(kept) == 0 {
+		return page, nil
+	}
+
+	i := 0 // first bucket of the page
+	for i < len(times) && after != nil && !times[i].After(*after) {
+		i++
+	}
+	j := len(times) // past the last bucket of the page
+	if q.PageSize > 0 {
+		j = min(j, i+max(1, q.PageSize/len(kept)))
+	}
+	if i == j {
+		return page, nil
+	}
+	until := times[j-1]
+	if j < len(times) {
+		page.NextCursor = EncodeCursor(&statisticsrepo.Cursor{Bucket: until})
 	}
 
-	slices.SortFunc(posts, func(a, b Post) int {
-		if a.PolledTimeRoundedMinute.Before(b.PolledTimeRoundedMinute) {
-			return -1
+	postsDb, err := s.repo.Stats(f, after, &until)
+	if err != nil {
+		return Page{}, err
+	}
+	heads, err := s.repo.StatsFirst(f, nil, kept)
+	if err != nil {
+		return Page{}, err
+	}
+	var before, next []statisticsrepo.Post
+	if q.Backfill == BackfillLOCF || q.Backfill == BackfillLinear {
+		if before, err = s.repo.StatsLast(f, times[i], kept); err != nil {
+			return Page{}, err
+		}
+	}
+	if q.Backfill == BackfillLinear {
+		if next, err = s.repo.StatsFirst(f, &until, kept); err != nil {
+			return Page{}, err
 		}
+	}
 
-		if b.PolledTimeRoundedMinute.Before(a.PolledTimeRoundedMinute) {
-			return 1
+	page.Posts = backfill(postsDb, times[i:j], q.Backfill, heads, before, next)
+	if q.Derived {
+		if err := s.derive(q, times[i], page.Posts); err != nil {
+			return Page{}, err
 		}
-		if a.Rank != nil && b.Rank != nil {
-			if *a.Rank < *b.Rank {
-				return -1
-			}
-			if *b.Rank < *a.Rank {
-				return 1
-			}
-		} else {
-			log.Printf("?? why some nil")
+	}
+	return page, nil
+}
+
+// derive sets Derived on the posts of a page starting at the bucket start, seeded from every
+// bucket of the post before the page regardless of FromTime.
+func (s Service) derive(q StatsQuery, start time.Time, posts []Post) error {
+	var dataKsIds []string
+	seen := make(map[string]struct{})
+	for _, p := range posts {
+		if _, ok := seen[p.DataKsId]; !ok {
+			seen[p.DataKsId] = struct{}{}
+			dataKsIds = append(dataKsIds, p.DataKsId)
 		}
-		return 0
-	})
-	return posts, nil
+	}
+	if len(dataKsIds) == 0 {
+		return nil
+	}
+
+	f := q.filter()
+	f.FromTime = nil
+	seeds, err := s.repo.StatsLast(f, start, dataKsIds)
+	if err != nil {
+		return err
+	}
+	seedsInTopN, err := s.repo.StatsInTopN(f, start, q.TopN, dataKsIds)
+	if err != nil {
+		return err
+	}
+	derive(posts, GranularityToDuration[q.Granularity], q.TopN, seeds, seedsInTopN)
+	return nil
 }
 
 func cloneType(p statisticsrepo.Post) Post {
@@ -303,5 +459,12 @@ func cloneType(p statisticsrepo.Post) Post {
 		RankOrderForCreatedWithinPast: p.RankOrderForCreatedWithinPast,
 		Rank:                          &p.Rank,
 		IsSynthetic:                   false,
+		PostCreatedAt:                 p.PostCreatedAt,
+		MinRank:                       p.MinRank,
+		MaxRank:                       p.MaxRank,
+		AvgRank:                       p.AvgRank,
+		MinScore:                      p.MinScore,
+		MaxScore:                      p.MaxScore,
+		AvgScore:                      p.AvgScore,
 	}
 }
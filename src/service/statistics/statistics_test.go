@@ -0,0 +1,73 @@
+package statistics
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	c := &statisticsrepo.Cursor{Bucket: at(15)}
+	tests := []struct {
+		name    string
+		cursor  string
+		want    *statisticsrepo.Cursor
+		wantErr bool
+	}{
+		{"empty is the first page", "", nil, false},
+		{"round trip", EncodeCursor(c), c, false},
+		{"not base64", "not a cursor!", nil, true},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("bucket")), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Bucket.Equal(tt.want.Bucket)) {
+				t.Fatalf("cursor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeCursorOfLastPage(t *testing.T) {
+	if got := EncodeCursor(nil); got != "" {
+		t.Fatalf("cursor = %q, want empty", got)
+	}
+}
+
+var t0 = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(minutes int) time.Time {
+	return t0.Add(time.Duration(minutes) * time.Minute)
+}
+
+func observation(dataKsId string, minutes int, rank, score int32) statisticsrepo.Post {
+	return statisticsrepo.Post{
+		Title:                   "title " + dataKsId,
+		DataKsId:                dataKsId,
+		PolledTime:              at(minutes),
+		PolledTimeRoundedMinute: at(minutes),
+		Rank:                    rank,
+		Score:                   score,
+		CommentCount:            score / 10,
+	}
+}
+
+func equalInt(a, b *int32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func deref(v *int32) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,153 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/noellimx/redditminer/src/infrastructure/repositories/task"
+)
+
+type ChangeAction string
+
+const (
+	ChangeActionCreate    ChangeAction = "create"
+	ChangeActionUpdate    ChangeAction = "update"
+	ChangeActionDelete    ChangeAction = "delete"
+	ChangeActionUnchanged ChangeAction = "unchanged"
+)
+
+// Change is one line of an import plan. Before is nil on create, After is nil on delete.
+type Change struct {
+	Action ChangeAction
+	Key    string
+	Before *task.Task
+	After  *task.Task
+}
+
+// Key identifies a task the same way the unique index on active tasks does.
+func Key(t task.Task) string {
+	return fmt.Sprintf("%s/%s/%s/%s", strings.ToLower(t.SubRedditName), t.Interval, t.OrderBy, t.PostsCreatedWithinPast)
+}
+
+// Export returns the active tasks as definitions.
+func (s Service) Export() ([]Definition, error) {
+	tasks, err := s.repo.GetActiveTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []Definition
+	for _, t := range tasks {
+		definitions = append(definitions, Definition{
+			SubredditName:          t.SubRedditName,
+			MinItemCount:           t.MinItemCount,
+			Interval:               string(t.Interval),
+			OrderBy:                string(t.OrderBy),
+			PostsCreatedWithinPast: string(t.PostsCreatedWithinPast),
+			CatchUpPolicy:          string(t.CatchUpPolicy),
+			Limits:                 t.Limits,
+			ScraperOptions:         t.ScraperOptions,
+		})
+	}
+	return definitions, nil
+}
+
+// Import upserts the definitions into the active tasks by Key.
+// A task already past its end is imported as is, and completed by the scheduler on its next window.
+// With prune, active tasks missing from the definitions are deleted.
+// With dryRun, the plan is returned without being applied.
+func (s Service) Import(definitions []Definition, prune bool, dryRun bool) ([]Change, error) {
+	wanted := make(map[string]task.Task)
+	var order []string
+	for i, d := range definitions {
+		t, err := toTask(d)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %w", i, err)
+		}
+		key := Key(t)
+		if _, ok := wanted[key]; ok {
+			return nil, fmt.Errorf("task %d: %s is declared more than once", i, key)
+		}
+		wanted[key] = t
+		order = append(order, key)
+	}
+
+	existing, err := s.repo.GetActiveTasks()
+	if err != nil {
+		return nil, err
+	}
+	existingByKey := make(map[string]task.Task)
+	for _, t := range existing {
+		existingByKey[Key(t)] = t
+	}
+
+	var changes []Change
+	var creates, updates []task.Task
+	var deletes []int64
+	for _, key := range order {
+		after := wanted[key]
+		before, ok := existingByKey[key]
+		if !ok {
+			changes = append(changes, Change{Action: ChangeActionCreate, Key: key, After: &after})
+			creates = append(creates, after)
+			continue
+		}
+
+		after.Id = before.Id
+		if sameSettings(before, after) {
+			changes = append(changes, Change{Action: ChangeActionUnchanged, Key: key, Before: &before, After: &after})
+			continue
+		}
+		changes = append(changes, Change{Action: ChangeActionUpdate, Key: key, Before: &before, After: &after})
+		updates = append(updates, after)
+	}
+
+	if prune {
+		for _, before := range existing {
+			key := Key(before)
+			if _, ok := wanted[key]; ok {
+				continue
+			}
+			changes = append(changes, Change{Action: ChangeActionDelete, Key: key, Before: &before})
+			deletes = append(deletes, before.Id)
+		}
+	}
+
+	if dryRun {
+		return changes, nil
+	}
+	return changes, s.repo.Sync(creates, updates, deletes)
+}
+
+// sameSettings compares the fields an import may update. Key fields are equal by construction,
+// up to the casing of the subreddit name.
+func sameSettings(a, b task.Task) bool {
+	return a.SubRedditName == b.SubRedditName &&
+		a.MinItemCount == b.MinItemCount &&
+		a.CatchUpPolicy == b.CatchUpPolicy &&
+		sameTime(a.StartsAt, b.StartsAt) &&
+		sameTime(a.EndsAt, b.EndsAt) &&
+		sameInt(a.MaxRuns, b.MaxRuns) &&
+		sameScraperOptions(a.ScraperOptions, b.ScraperOptions)
+}
+
+func sameScraperOptions(a, b task.ScraperOptions) bool {
+	aWait, bWait := a.ScrollWaitSeconds, b.ScrollWaitSeconds
+	a.ScrollWaitSeconds, b.ScrollWaitSeconds = nil, nil
+	return a == b && sameInt(aWait, bWait)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameInt[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -2,29 +2,187 @@ package task
 
 import (
 	"fmt"
+	"regexp"
+	"time"
 
+	"github.com/noellimx/redditminer/src/infrastructure/reddit_miner"
 	"github.com/noellimx/redditminer/src/infrastructure/repositories/task"
 )
 
+// SubredditValidator checks on create that a subreddit exists and can be scraped.
+type SubredditValidator interface {
+	ValidateSubReddit(name string) error
+}
+
+type SubredditValidatorFunc func(name string) error
+
+func (f SubredditValidatorFunc) ValidateSubReddit(name string) error {
+	return f(name)
+}
+
+// store is the part of the task repo the service uses.
+type store interface {
+	Create(t task.Task) error
+	Delete(id int64) error
+	GetTasksByInterval(every task.Granularity) ([]task.Task, error)
+	GetTasks() ([]task.Task, error)
+	UpdateScraperOptions(id int64, options task.ScraperOptions) error
+	Complete(id int64) error
+	CountRuns(id int64) (int64, error)
+	GetMissedWindows(interval task.Granularity, every time.Duration, since time.Time, until time.Time) ([]task.MissedWindow, error)
+	GetActiveTasks() ([]task.Task, error)
+	Sync(creates []task.Task, updates []task.Task, deletes []int64) error
+}
+
 type Service struct {
-	repo *task.Repo
+	repo      store
+	validator SubredditValidator
 }
 
-func New(repo *task.Repo) *Service {
-	return &Service{repo: repo}
+// New takes an optional validator. Without one, create requests asking for validation are rejected.
+func New(repo *task.Repo, validator SubredditValidator) *Service {
+	return &Service{repo: repo, validator: validator}
 }
 
-func (s Service) Create(name string, count int64, _interval string, by string, past string) error {
-	orderBy := task.OrderByAlgo(by)
-	interval := task.Granularity(_interval)
+var subredditNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_]{1,20}$`)
+
+var GranularityToDuration = map[task.Granularity]time.Duration{
+	task.GranularityHour: time.Hour,
+}
+
+// Definition is a task as declared by a client, before validation.
+type Definition struct {
+	SubredditName          string
+	MinItemCount           int64
+	Interval               string
+	OrderBy                string
+	PostsCreatedWithinPast string
+	CatchUpPolicy          string
+	task.Limits
+	ScraperOptions task.ScraperOptions
+}
+
+// Create rejects a duplicate of an active task with task.ErrDuplicate, and a task already past its end.
+// When validate is set the subreddit is checked against reddit before the task is stored.
+func (s Service) Create(d Definition, validate bool) error {
+	t, err := toTask(d)
+	if err != nil {
+		return err
+	}
+	if t.EndsAt != nil && !t.EndsAt.After(time.Now()) {
+		return fmt.Errorf("invalid params, ends at %v is in the past", t.EndsAt)
+	}
+
+	if validate {
+		if s.validator == nil {
+			return fmt.Errorf("subreddit validation is not available")
+		}
+		if err := s.validator.ValidateSubReddit(t.SubRedditName); err != nil {
+			return err
+		}
+	}
+	return s.repo.Create(t)
+}
+
+func toTask(d Definition) (task.Task, error) {
+	name, count, past := d.SubredditName, d.MinItemCount, d.PostsCreatedWithinPast
+	orderBy := task.OrderByAlgo(d.OrderBy)
+	interval := task.Granularity(d.Interval)
 	if name == "" || count <= 0 || interval == "" || orderBy == "" || past == "" {
-		return fmt.Errorf("some invalid params, name=%v, count=%v, interval=%v, by=%v, past %v", name, count, interval, orderBy, past)
+		return task.Task{}, fmt.Errorf("some invalid params, name=%v, count=%v, interval=%v, by=%v, past %v", name, count, interval, orderBy, past)
 	}
 
 	if interval != task.GranularityHour {
-		return fmt.Errorf("invalid params, interval requested at %v but only support %v", interval, task.GranularityHour)
+		return task.Task{}, fmt.Errorf("invalid params, interval requested at %v but only support %v", interval, task.GranularityHour)
+	}
+
+	catchUp := task.CatchUpPolicy(d.CatchUpPolicy)
+	switch catchUp {
+	case "":
+		catchUp = task.CatchUpPolicySkip
+	case task.CatchUpPolicySkip, task.CatchUpPolicyOnce, task.CatchUpPolicyAll:
+	default:
+		return task.Task{}, fmt.Errorf("invalid params, catch up policy %v not one of %v, %v, %v", catchUp, task.CatchUpPolicySkip, task.CatchUpPolicyOnce, task.CatchUpPolicyAll)
+	}
+
+	limits := d.Limits
+	if limits.StartsAt != nil && limits.EndsAt != nil && !limits.EndsAt.After(*limits.StartsAt) {
+		return task.Task{}, fmt.Errorf("invalid params, ends at %v is not after starts at %v", limits.EndsAt, limits.StartsAt)
+	}
+	if limits.MaxRuns != nil && *limits.MaxRuns <= 0 {
+		return task.Task{}, fmt.Errorf("invalid params, max runs %v must be positive", *limits.MaxRuns)
+	}
+
+	if !subredditNamePattern.MatchString(name) {
+		return task.Task{}, fmt.Errorf("invalid params, %v is not a valid subreddit name", name)
+	}
+
+	if err := validateScraperOptions(d.ScraperOptions); err != nil {
+		return task.Task{}, err
+	}
+
+	return task.Task{
+		SubRedditName:          name,
+		MinItemCount:           count,
+		Interval:               interval,
+		OrderBy:                orderBy,
+		PostsCreatedWithinPast: task.CreatedWithinPast(past),
+		CatchUpPolicy:          catchUp,
+		Limits:                 limits,
+		Status:                 task.StatusActive,
+		ScraperOptions:         d.ScraperOptions,
+	}, nil
+}
+
+func validateScraperOptions(o task.ScraperOptions) error {
+	switch reddit_miner.ScrollStrategy(o.ScrollStrategy) {
+	case "", reddit_miner.ScrollStrategyNone, reddit_miner.ScrollStrategyOnce, reddit_miner.ScrollStrategyUntilCount:
+	default:
+		return fmt.Errorf("invalid scraper options, scroll strategy %v not one of %v, %v, %v", o.ScrollStrategy, reddit_miner.ScrollStrategyNone, reddit_miner.ScrollStrategyOnce, reddit_miner.ScrollStrategyUntilCount)
+	}
+	if o.ScrollWaitSeconds != nil && (*o.ScrollWaitSeconds < 0 || *o.ScrollWaitSeconds > 60) {
+		return fmt.Errorf("invalid scraper options, scroll wait seconds %v not within [0, 60]", *o.ScrollWaitSeconds)
 	}
-	return s.repo.Create(name, count, interval, orderBy, task.CreatedWithinPast(past))
+	if o.MaxScrolls < 0 || o.MaxScrolls > 20 {
+		return fmt.Errorf("invalid scraper options, max scrolls %v not within [0, 20]", o.MaxScrolls)
+	}
+	if o.MaxItems < 0 || o.MaxItems > 1000 {
+		return fmt.Errorf("invalid scraper options, max items %v not within [0, 1000]", o.MaxItems)
+	}
+	if o.Retries < 0 || o.Retries > 5 {
+		return fmt.Errorf("invalid scraper options, retries %v not within [0, 5]", o.Retries)
+	}
+	return nil
+}
+
+func (s Service) UpdateScraperOptions(id int64, options task.ScraperOptions) error {
+	if err := validateScraperOptions(options); err != nil {
+		return err
+	}
+	return s.repo.UpdateScraperOptions(id, options)
+}
+
+// ToScraperOptions resolves the stored options of the task against the scraper defaults.
+func ToScraperOptions(t task.Task, snapshotDir string) reddit_miner.Options {
+	opts := reddit_miner.DefaultOptions()
+	o := t.ScraperOptions
+	if o.ScrollStrategy != "" {
+		opts.ScrollStrategy = reddit_miner.ScrollStrategy(o.ScrollStrategy)
+	}
+	if o.ScrollWaitSeconds != nil {
+		opts.ScrollWait = time.Duration(*o.ScrollWaitSeconds) * time.Second
+	}
+	if o.MaxScrolls > 0 {
+		opts.MaxScrolls = o.MaxScrolls
+	}
+	opts.MinItems = int(t.MinItemCount)
+	opts.MaxItems = o.MaxItems
+	opts.DebugLogEnabled = o.DebugLogEnabled
+	if o.CaptureSnapshot {
+		opts.SnapshotDir = snapshotDir
+	}
+	opts.Retries = o.Retries
+	return opts
 }
 
 func (s Service) Delete(id int64) error {
@@ -48,3 +206,35 @@ func (s Service) GetTasks() ([]task.Task, error) {
 
 	return tasks, nil
 }
+
+// LimitReached reports whether the task, as of the window, is past its end or has used up its runs.
+// Runs are counted per window of the task's interval, however many scrapes succeeded within it.
+func (s Service) LimitReached(t task.Task, window time.Time) (bool, error) {
+	if t.EndsAt != nil && !window.Before(*t.EndsAt) {
+		return true, nil
+	}
+	if t.MaxRuns == nil {
+		return false, nil
+	}
+
+	runs, err := s.repo.CountRuns(t.Id)
+	if err != nil {
+		return false, err
+	}
+	return runs >= *t.MaxRuns, nil
+}
+
+func (s Service) Complete(id int64) error {
+	return s.repo.Complete(id)
+}
+
+// GetMissedWindows lists the fully elapsed windows within the lookback where a task of the interval has no succeeded run.
+func (s Service) GetMissedWindows(interval task.Granularity, lookback time.Duration) ([]task.MissedWindow, error) {
+	every := GranularityToDuration[interval]
+	if every == 0 {
+		return nil, fmt.Errorf("unknown conversion from interval to duration. =%s", interval)
+	}
+
+	now := time.Now().UTC()
+	return s.repo.GetMissedWindows(interval, every, now.Add(-lookback), now)
+}
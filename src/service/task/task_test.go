@@ -0,0 +1,229 @@
+package task
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/noellimx/redditminer/src/infrastructure/repositories/task"
+)
+
+// stubStore records the tasks created and returns active from GetActiveTasks.
+type stubStore struct {
+	created   []task.Task
+	createErr error
+	active    []task.Task
+}
+
+var _ store = (*stubStore)(nil)
+
+func (s *stubStore) Create(t task.Task) error {
+	if s.createErr != nil {
+		return s.createErr
+	}
+	s.created = append(s.created, t)
+	return nil
+}
+
+func (s *stubStore) Delete(id int64) error {
+	return nil
+}
+
+func (s *stubStore) GetTasksByInterval(every task.Granularity) ([]task.Task, error) {
+	return nil, nil
+}
+
+func (s *stubStore) GetTasks() ([]task.Task, error) {
+	return s.active, nil
+}
+
+func (s *stubStore) UpdateScraperOptions(id int64, options task.ScraperOptions) error {
+	return nil
+}
+
+func (s *stubStore) Complete(id int64) error {
+	return nil
+}
+
+func (s *stubStore) CountRuns(id int64) (int64, error) {
+	return 0, nil
+}
+
+func (s *stubStore) GetMissedWindows(interval task.Granularity, every time.Duration, since time.Time, until time.Time) ([]task.MissedWindow, error) {
+	return nil, nil
+}
+
+func (s *stubStore) GetActiveTasks() ([]task.Task, error) {
+	return s.active, nil
+}
+
+func (s *stubStore) Sync(creates []task.Task, updates []task.Task, deletes []int64) error {
+	return nil
+}
+
+func validDefinition() Definition {
+	return Definition{
+		SubredditName:          "golang",
+		MinItemCount:           10,
+		Interval:               string(task.GranularityHour),
+		OrderBy:                string(task.OrderByAlgoTop),
+		PostsCreatedWithinPast: string(task.CreatedWithinPastDay),
+	}
+}
+
+func TestCreateValidatesSubreddit(t *testing.T) {
+	errScraper := errors.New("unexpected about response for golang: EOF")
+	tests := []struct {
+		name      string
+		validator SubredditValidator
+		createErr error
+		wantErr   error // nil for success; compared with errors.Is
+		wantAnErr bool
+		created   bool
+	}{
+		{
+			name:      "exists",
+			validator: SubredditValidatorFunc(func(string) error { return nil }),
+			created:   true,
+		},
+		{
+			name:      "private",
+			validator: SubredditValidatorFunc(func(name string) error { return errors.New("subreddit " + name + " is private") }),
+			wantAnErr: true,
+		},
+		{
+			name:      "banned",
+			validator: SubredditValidatorFunc(func(name string) error { return errors.New("subreddit " + name + " is not accessible: banned") }),
+			wantAnErr: true,
+		},
+		{
+			name:      "not found",
+			validator: SubredditValidatorFunc(func(name string) error { return errors.New("subreddit " + name + " does not exist") }),
+			wantAnErr: true,
+		},
+		{
+			name:      "scraper error",
+			validator: SubredditValidatorFunc(func(string) error { return errScraper }),
+			wantErr:   errScraper,
+		},
+		{
+			name:      "no validator",
+			wantAnErr: true,
+		},
+		{
+			name:      "duplicate",
+			validator: SubredditValidatorFunc(func(string) error { return nil }),
+			createErr: task.ErrDuplicate,
+			wantErr:   task.ErrDuplicate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubStore{createErr: tt.createErr}
+			s := Service{repo: repo, validator: tt.validator}
+
+			err := s.Create(validDefinition(), true)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantAnErr:
+				if err == nil {
+					t.Fatal("err = nil, want an error")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+			}
+			if got := len(repo.created) == 1; got != tt.created {
+				t.Fatalf("created = %v, want %v", got, tt.created)
+			}
+		})
+	}
+}
+
+func TestCreateRejectsInvalidDefinitions(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	later := time.Now().Add(2 * time.Hour)
+	zero, tooLong := int64(0), 61
+
+	tests := []struct {
+		name   string
+		change func(d *Definition)
+	}{
+		{"empty name", func(d *Definition) { d.SubredditName = "" }},
+		{"invalid name", func(d *Definition) { d.SubredditName = "r/golang" }},
+		{"no min item count", func(d *Definition) { d.MinItemCount = 0 }},
+		{"unsupported interval", func(d *Definition) { d.Interval = "day" }},
+		{"unknown catch up policy", func(d *Definition) { d.CatchUpPolicy = "twice" }},
+		{"ends before it starts", func(d *Definition) { d.StartsAt, d.EndsAt = &later, &future }},
+		{"ends in the past", func(d *Definition) { d.EndsAt = &past }},
+		{"no runs", func(d *Definition) { d.MaxRuns = &zero }},
+		{"unknown scroll strategy", func(d *Definition) { d.ScraperOptions.ScrollStrategy = "forever" }},
+		{"scroll wait too long", func(d *Definition) { d.ScraperOptions.ScrollWaitSeconds = &tooLong }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubStore{}
+			validated := false
+			s := Service{repo: repo, validator: SubredditValidatorFunc(func(string) error {
+				validated = true
+				return nil
+			})}
+
+			d := validDefinition()
+			tt.change(&d)
+			if err := s.Create(d, true); err == nil {
+				t.Fatal("err = nil, want an error")
+			}
+			if validated || len(repo.created) > 0 {
+				t.Fatalf("validated = %v, created = %d, want neither", validated, len(repo.created))
+			}
+		})
+	}
+}
+
+func TestCreateDefaults(t *testing.T) {
+	repo := &stubStore{}
+	s := Service{repo: repo}
+
+	if err := s.Create(validDefinition(), false); err != nil {
+		t.Fatal(err)
+	}
+	got := repo.created[0]
+	if got.CatchUpPolicy != task.CatchUpPolicySkip || got.Status != task.StatusActive {
+		t.Fatalf("catch up policy = %s, status = %s, want skip and active", got.CatchUpPolicy, got.Status)
+	}
+}
+
+func TestImportPlansTasksPastTheirEnd(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	existing, _ := toTask(validDefinition())
+	existing.Id = 7
+	s := Service{repo: &stubStore{active: []task.Task{existing}}}
+
+	renamed := validDefinition()
+	renamed.SubredditName = "GoLang"
+	ended := validDefinition()
+	ended.SubredditName = "rust"
+	ended.EndsAt = &past
+
+	changes, err := s.Import([]Definition{renamed, ended}, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ChangeAction{ChangeActionUpdate, ChangeActionCreate}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %d, want %d", len(changes), len(want))
+	}
+	for i, c := range changes {
+		if c.Action != want[i] {
+			t.Errorf("change %d = %s, want %s", i, c.Action, want[i])
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package trending
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	trendingrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/trending"
+)
+
+// MinBaselineSamples is how many earlier changes a ranking context needs before its posts are scored.
+const MinBaselineSamples = 30
+
+// BaselineRefresh is how long a ranking context's baseline is reused, the schedule of the rollups.
+const BaselineRefresh = 5 * time.Minute
+
+// Notifier is told about newly detected events.
+type Notifier interface {
+	Notify(events []trendingrepo.Event) error
+}
+
+type NotifierFunc func(events []trendingrepo.Event) error
+
+func (f NotifierFunc) Notify(events []trendingrepo.Event) error {
+	return f(events)
+}
+
+// LogNotifier logs every event.
+var LogNotifier = NotifierFunc(func(events []trendingrepo.Event) error {
+	for _, e := range events {
+		log.Printf("TRENDING subreddit=%s post=%s rank=%d score_z=%s rank_z=%s title=%q\n",
+			e.SubredditName, e.DataKsId, e.Rank, formatZ(e.ScoreZ), formatZ(e.RankZ), e.Title)
+	}
+	return nil
+})
+
+func formatZ(z *float64) string {
+	if z == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *z)
+}
+
+// WebhookNotifier posts the events as a JSON array to the url.
+func WebhookNotifier(url string) Notifier {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return NotifierFunc(func(events []trendingrepo.Event) error {
+		b, err := json.Marshal(events)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("trending webhook %s status %s", url, resp.Status)
+		}
+		return nil
+	})
+}
+
+type baselineKey struct {
+	subredditName string
+	orderType     string
+	past          string
+}
+
+type cachedBaseline struct {
+	trendingrepo.Baseline
+	refreshedAt time.Time
+}
+
+type Service struct {
+	repo      *trendingrepo.Repo
+	threshold float64       // z-score at or above which a post is trending
+	baseline  time.Duration // history the baseline is computed from
+	notifiers []Notifier
+
+	mu        *sync.Mutex
+	baselines map[baselineKey]cachedBaseline
+}
+
+func New(repo *trendingrepo.Repo, threshold float64, baseline time.Duration, notifiers ...Notifier) *Service {
+	return &Service{
+		repo:      repo,
+		threshold: threshold,
+		baseline:  baseline,
+		notifiers: notifiers,
+		mu:        &sync.Mutex{},
+		baselines: make(map[baselineKey]cachedBaseline),
+	}
+}
+
+// getBaseline returns the baseline of the ranking context, recomputed once it is older than BaselineRefresh.
+func (s Service) getBaseline(subredditName string, orderType string, past string) (trendingrepo.Baseline, error) {
+	key := baselineKey{subredditName: strings.ToLower(subredditName), orderType: orderType, past: past}
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	cached, ok := s.baselines[key]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.refreshedAt) < BaselineRefresh {
+		return cached.Baseline, nil
+	}
+
+	b, err := s.repo.GetBaseline(subredditName, orderType, past, now.Add(-s.baseline))
+	if err != nil {
+		return trendingrepo.Baseline{}, err
+	}
+	s.mu.Lock()
+	s.baselines[key] = cachedBaseline{Baseline: b, refreshedAt: now}
+	s.mu.Unlock()
+	return b, nil
+}
+
+// Detect scores the posts of the latest poll of the subreddit and ranking context against the context's baseline,
+// stores the posts whose score or rank velocity z-score reaches the threshold, and notifies the new ones.
+func (s Service) Detect(subredditName string, orderType string, past string) ([]trendingrepo.Event, error) {
+	b, err := s.getBaseline(subredditName, orderType, past)
+	if err != nil {
+		return nil, err
+	}
+	if b.Samples < MinBaselineSamples {
+		return nil, nil
+	}
+
+	velocities, err := s.repo.GetVelocities(subredditName, orderType, past, time.Now().UTC().Add(-s.baseline))
+	if err != nil {
+		return nil, err
+	}
+
+	var events []trendingrepo.Event
+	for _, v := range velocities {
+		var scoreZ *float64
+		if v.ScoreVelocity != nil {
+			scoreZ = zScore(*v.ScoreVelocity, b.ScoreMean, b.ScoreStdDev)
+		}
+		rankZ := zScore(v.RankVelocity, b.RankMean, b.RankStdDev)
+		if !(reaches(scoreZ, s.threshold) || reaches(rankZ, s.threshold)) {
+			continue
+		}
+
+		rankVelocity := v.RankVelocity
+		events = append(events, trendingrepo.Event{
+			PostId:                        v.PostId,
+			DataKsId:                      v.DataKsId,
+			Title:                         v.Title,
+			PermaLinkPath:                 v.PermaLinkPath,
+			SubredditName:                 v.SubredditName,
+			RankOrderType:                 orderType,
+			RankOrderForCreatedWithinPast: past,
+			PolledTimeRoundedMinute:       v.Bucket,
+			Rank:                          v.Rank,
+			Score:                         v.Score,
+			ScoreVelocity:                 v.ScoreVelocity,
+			RankVelocity:                  &rankVelocity,
+			ScoreZ:                        scoreZ,
+			RankZ:                         rankZ,
+			Threshold:                     s.threshold,
+		})
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	// a retried scrape of the same bucket finds the events it already stored
+	detected, err := s.repo.InsertEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	if len(detected) == 0 {
+		return nil, nil
+	}
+
+	for _, n := range s.notifiers {
+		if err := n.Notify(detected); err != nil {
+			log.Printf("trending notify error=%v\n", err)
+		}
+	}
+	return detected, nil
+}
+
+func zScore(v float64, mean *float64, stdDev *float64) *float64 {
+	if mean == nil || stdDev == nil || *stdDev == 0 {
+		return nil
+	}
+	z := (v - *mean) / *stdDev
+	return &z
+}
+
+func reaches(z *float64, threshold float64) bool {
+	return z != nil && !math.IsNaN(*z) && *z >= threshold
+}
+
+// Events lists the events of the subreddit detected since the time, newest first.
+func (s Service) Events(subredditName string, since time.Time, limit int) ([]trendingrepo.Event, error) {
+	if subredditName == "" {
+		return nil, fmt.Errorf("empty subreddit name")
+	}
+	return s.repo.GetEvents(subredditName, since, limit)
+}
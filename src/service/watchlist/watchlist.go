@@ -0,0 +1,254 @@
+package watchlist
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	statisticsrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/statistics"
+	watchlistrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/watchlist"
+	statisticsservice "github.com/noellimx/redditminer/src/service/statistics"
+)
+
+const (
+	MaxKeywords    = 100
+	MaxSeriesRange = 92 * 24 * time.Hour
+	MaxBuckets     = 5000
+)
+
+type Service struct {
+	repo *watchlistrepo.Repo
+}
+
+func New(repo *watchlistrepo.Repo) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// Definition is a watchlist as created or updated.
+type Definition struct {
+	Name       string
+	Subreddits []string // empty for every subreddit
+	Keywords   []watchlistrepo.Keyword
+}
+
+// compile matches a plain keyword as a whole phrase, not within a longer word. Case insensitive unless asked.
+func compile(k watchlistrepo.Keyword) (*regexp.Regexp, error) {
+	expr := k.Keyword
+	if !k.IsRegex {
+		expr = `(?:^|[^\pL\pN_])` + regexp.QuoteMeta(k.Keyword) + `(?:$|[^\pL\pN_])`
+	}
+	if !k.CaseSensitive {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+func (d Definition) toWatchlist() (watchlistrepo.Watchlist, error) {
+	w := watchlistrepo.Watchlist{
+		Name:       strings.TrimSpace(d.Name),
+		Subreddits: []string{},
+	}
+	if w.Name == "" {
+		return watchlistrepo.Watchlist{}, fmt.Errorf("empty watchlist name")
+	}
+	for _, name := range d.Subreddits {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "r/")))
+		if name == "" {
+			return watchlistrepo.Watchlist{}, fmt.Errorf("empty subreddit name")
+		}
+		if !slices.Contains(w.Subreddits, name) {
+			w.Subreddits = append(w.Subreddits, name)
+		}
+	}
+	if len(d.Keywords) == 0 || len(d.Keywords) > MaxKeywords {
+		return watchlistrepo.Watchlist{}, fmt.Errorf("%d keywords not supported, need 1 to %d", len(d.Keywords), MaxKeywords)
+	}
+	for _, k := range d.Keywords {
+		if strings.TrimSpace(k.Keyword) == "" {
+			return watchlistrepo.Watchlist{}, fmt.Errorf("empty keyword")
+		}
+		if _, err := compile(k); err != nil {
+			return watchlistrepo.Watchlist{}, fmt.Errorf("keyword %q: %w", k.Keyword, err)
+		}
+		w.Keywords = append(w.Keywords, watchlistrepo.Keyword{Keyword: k.Keyword, IsRegex: k.IsRegex, CaseSensitive: k.CaseSensitive})
+	}
+	return w, nil
+}
+
+// Create rejects a watchlist named as an existing one with watchlist.ErrDuplicate.
+func (s Service) Create(d Definition) (watchlistrepo.Watchlist, error) {
+	w, err := d.toWatchlist()
+	if err != nil {
+		return watchlistrepo.Watchlist{}, err
+	}
+	id, err := s.repo.Create(w)
+	if err != nil {
+		return watchlistrepo.Watchlist{}, err
+	}
+	return s.repo.Get(id)
+}
+
+// Update replaces the watchlist. Matches of the keywords kept are kept, matches of the removed ones are deleted.
+func (s Service) Update(id int64, d Definition) (watchlistrepo.Watchlist, error) {
+	w, err := d.toWatchlist()
+	if err != nil {
+		return watchlistrepo.Watchlist{}, err
+	}
+	w.Id = id
+	if err := s.repo.Update(w); err != nil {
+		return watchlistrepo.Watchlist{}, err
+	}
+	return s.repo.Get(id)
+}
+
+func (s Service) Delete(id int64) error {
+	return s.repo.Delete(id)
+}
+
+func (s Service) Get(id int64) (watchlistrepo.Watchlist, error) {
+	return s.repo.Get(id)
+}
+
+func (s Service) GetWatchlists() ([]watchlistrepo.Watchlist, error) {
+	return s.repo.GetWatchlists()
+}
+
+// Watch matches the titles of the scraped posts against every watchlist of their subreddit and stores the matches.
+func (s Service) Watch(posts []statisticsrepo.PostForm) error {
+	watchlists, err := s.repo.GetWatchlists()
+	if err != nil {
+		return err
+	}
+
+	type matcher struct {
+		keywordId  int64
+		subreddits []string
+		re         *regexp.Regexp
+	}
+	var matchers []matcher
+	for _, w := range watchlists {
+		for _, k := range w.Keywords {
+			re, err := compile(k)
+			if err != nil {
+				return fmt.Errorf("watchlist %d keyword %q: %w", w.Id, k.Keyword, err)
+			}
+			matchers = append(matchers, matcher{keywordId: k.Id, subreddits: w.Subreddits, re: re})
+		}
+	}
+	if len(matchers) == 0 {
+		return nil
+	}
+
+	var matches []watchlistrepo.Match
+	for _, p := range posts {
+		subreddit := strings.ToLower(p.SubredditName)
+		for _, m := range matchers {
+			if len(m.subreddits) > 0 && !slices.Contains(m.subreddits, subreddit) {
+				continue
+			}
+			if !m.re.MatchString(p.Title) {
+				continue
+			}
+			matches = append(matches, watchlistrepo.Match{
+				KeywordId:                     m.keywordId,
+				DataKsId:                      p.DataKsId,
+				SubredditName:                 p.SubredditName,
+				RankOrderType:                 string(p.RankOrderType),
+				RankOrderForCreatedWithinPast: string(p.RankOrderForCreatedWithinPast),
+				PolledTimeRoundedMinute:       p.PolledTimeRoundedMinute,
+				Rank:                          p.Rank,
+				Score:                         p.Score,
+			})
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	return s.repo.InsertMatches(matches)
+}
+
+// KeywordSeries counts the matches of one keyword per bucket, aligned with Series.Buckets.
+type KeywordSeries struct {
+	Keyword  watchlistrepo.Keyword
+	Mentions []int64  // distinct posts matched
+	InTopN   []int64  // distinct posts matched at or above the top n rank
+	BestRank []*int32 // nil without matches
+}
+
+type Series struct {
+	Watchlist watchlistrepo.Watchlist
+	Buckets   []time.Time
+	Keywords  []KeywordSeries // in the order of the watchlist's keywords
+}
+
+// Series counts the mentions and top n presence of the watchlist's keywords per bucket of the granularity in [from, to),
+// in the ranking context, or in every context when orderType and past are empty.
+func (s Service) Series(id int64, from time.Time, to time.Time, granularity statisticsrepo.Granularity, orderType string, past string, topN int32) (Series, error) {
+	if !from.Before(to) {
+		return Series{}, fmt.Errorf("from time %s is not before to time %s", from, to)
+	}
+	if to.Sub(from) > MaxSeriesRange {
+		return Series{}, fmt.Errorf("time range %s is longer than %s", to.Sub(from), MaxSeriesRange)
+	}
+	step, ok := statisticsservice.GranularityToDuration[granularity]
+	if !ok {
+		return Series{}, fmt.Errorf("granularity type not supported. =%d", granularity)
+	}
+	if topN < 1 {
+		return Series{}, fmt.Errorf("top n %d not supported, need at least 1", topN)
+	}
+
+	w, err := s.repo.Get(id)
+	if err != nil {
+		return Series{}, err
+	}
+
+	var buckets []time.Time
+	for t := from.UTC().Truncate(step); t.Before(to); t = t.Add(step) {
+		buckets = append(buckets, t)
+		if len(buckets) > MaxBuckets {
+			return Series{}, fmt.Errorf("more than %d buckets, use a coarser granularity or a shorter range", MaxBuckets)
+		}
+	}
+	index := make(map[time.Time]int)
+	for i, t := range buckets {
+		index[t] = i
+	}
+
+	keywordBuckets, err := s.repo.Series(id, step, from, to, orderType, past, topN)
+	if err != nil {
+		return Series{}, err
+	}
+
+	series := Series{Watchlist: w, Buckets: buckets}
+	byKeyword := make(map[int64]int)
+	for i, k := range w.Keywords {
+		byKeyword[k.Id] = i
+		series.Keywords = append(series.Keywords, KeywordSeries{
+			Keyword:  k,
+			Mentions: make([]int64, len(buckets)),
+			InTopN:   make([]int64, len(buckets)),
+			BestRank: make([]*int32, len(buckets)),
+		})
+	}
+	for _, b := range keywordBuckets {
+		i, ok := index[b.Bucket.UTC()]
+		if !ok {
+			continue
+		}
+		k, ok := byKeyword[b.KeywordId]
+		if !ok {
+			continue
+		}
+		ks := &series.Keywords[k]
+		ks.Mentions[i] = b.Mentions
+		ks.InTopN[i] = b.InTopN
+		bestRank := b.BestRank
+		ks.BestRank[i] = &bestRank
+	}
+	return series, nil
+}
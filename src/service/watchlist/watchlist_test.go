@@ -0,0 +1,43 @@
+package watchlist
+
+import (
+	"testing"
+
+	watchlistrepo "github.com/noellimx/redditminer/src/infrastructure/repositories/watchlist"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyword watchlistrepo.Keyword
+		title   string
+		want    bool
+	}{
+		{"whole word", watchlistrepo.Keyword{Keyword: "go"}, "Why Go is fast", true},
+		{"not within a word", watchlistrepo.Keyword{Keyword: "go"}, "Google and golang", false},
+		{"phrase", watchlistrepo.Keyword{Keyword: "open source"}, "An open source release", true},
+		{"at the edges", watchlistrepo.Keyword{Keyword: "rust"}, "rust", true},
+		{"next to punctuation", watchlistrepo.Keyword{Keyword: "c++"}, "Learning C++, again", true},
+		{"case insensitive", watchlistrepo.Keyword{Keyword: "NASA"}, "nasa launches", true},
+		{"case sensitive", watchlistrepo.Keyword{Keyword: "NASA", CaseSensitive: true}, "nasa launches", false},
+		{"regex", watchlistrepo.Keyword{Keyword: `gpt-?\d`, IsRegex: true}, "Trying GPT4 today", true},
+		{"regex within a word", watchlistrepo.Keyword{Keyword: "lang", IsRegex: true}, "golang", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := compile(tt.keyword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := re.MatchString(tt.title); got != tt.want {
+				t.Fatalf("match %q in %q = %v, want %v", tt.keyword.Keyword, tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsInvalidRegex(t *testing.T) {
+	if _, err := compile(watchlistrepo.Keyword{Keyword: "(unclosed", IsRegex: true}); err == nil {
+		t.Fatal("err = nil, want an error")
+	}
+}
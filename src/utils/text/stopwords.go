@@ -0,0 +1,38 @@
+package text
+
+// stopwords are common English words, lower case and without apostrophes as Tokenize leaves them,
+// plus words common to post titles that say nothing about the topic. Contractions that spell another word
+// once the apostrophe is dropped, such as "we'll" and "shell", are left in.
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range []string{
+		"about", "above", "after", "again", "against", "all", "am", "an", "and", "any", "are", "arent", "as", "at",
+		"be", "because", "been", "before", "being", "below", "between", "both", "but", "by",
+		"can", "cant", "cannot", "could", "couldnt",
+		"did", "didnt", "do", "does", "doesnt", "doing", "dont", "down", "during",
+		"each", "few", "for", "from", "further",
+		"had", "hadnt", "has", "hasnt", "have", "havent", "having", "he", "hed", "hes", "her", "here", "heres",
+		"hers", "herself", "him", "himself", "his", "how", "hows",
+		"im", "ive", "if", "in", "into", "is", "isnt", "it", "its", "itself",
+		"just", "lets", "me", "more", "most", "mustnt", "my", "myself",
+		"no", "nor", "not", "now", "of", "off", "on", "once", "only", "or", "other", "ought", "our", "ours",
+		"ourselves", "out", "over", "own",
+		"same", "shant", "she", "shes", "should", "shouldnt", "so", "some", "such",
+		"than", "that", "thats", "the", "their", "theirs", "them", "themselves", "then", "there", "theres", "these",
+		"they", "theyd", "theyll", "theyre", "theyve", "this", "those", "through", "to", "too",
+		"under", "until", "up", "very",
+		"was", "wasnt", "we", "were", "weve", "werent", "what", "whats", "when", "whens", "where",
+		"wheres", "which", "while", "who", "whos", "whom", "why", "whys", "will", "with", "wont", "would", "wouldnt",
+		"you", "youd", "youll", "youre", "youve", "your", "yours", "yourself", "yourselves",
+		// title filler
+		"get", "got", "like", "new", "one", "really", "still", "thing", "things", "way", "also", "anyone", "know",
+	} {
+		stopwords[w] = true
+	}
+}
+
+// IsStopword reports whether the lower case word is left out of terms.
+func IsStopword(w string) bool {
+	return stopwords[w]
+}
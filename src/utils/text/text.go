@@ -0,0 +1,78 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words lower cases s and splits it into words of letters and digits.
+// Apostrophes within a word are dropped, so "don't" is "dont".
+func Words(s string) []string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+		}
+		word.Reset()
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		case r == '\'' || r == '’':
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// Tokenize is the Words of s without words of one character and stopwords.
+func Tokenize(s string) []string {
+	var tokens []string
+	for _, w := range Words(s) {
+		if !leftOut(w) {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+// leftOut reports whether the word is left out of tokens, and so cannot start or end a term.
+func leftOut(w string) bool {
+	return len([]rune(w)) < 2 || IsStopword(w)
+}
+
+// NGrams joins every run of n consecutive tokens with a space.
+func NGrams(tokens []string, n int) []string {
+	if n < 1 || len(tokens) < n {
+		return nil
+	}
+	grams := make([]string, 0, len(tokens)-n+1)
+	for i := 0; i+n <= len(tokens); i++ {
+		grams = append(grams, strings.Join(tokens[i:i+n], " "))
+	}
+	return grams
+}
+
+// Terms are the distinct n-grams of the Words of s for every n from 1 to maxN, in order of first appearance.
+// A gram may hold stopwords within, as in "state of the art", but does not start or end with one.
+func Terms(s string, maxN int) []string {
+	words := Words(s)
+	seen := make(map[string]bool)
+	var terms []string
+	for n := 1; n <= maxN; n++ {
+		for i, gram := range NGrams(words, n) {
+			if leftOut(words[i]) || leftOut(words[i+n-1]) {
+				continue
+			}
+			if !seen[gram] {
+				seen[gram] = true
+				terms = append(terms, gram)
+			}
+		}
+	}
+	return terms
+}
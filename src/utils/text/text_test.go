@@ -0,0 +1,61 @@
+package text
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"", nil},
+		{"Go 1.23 is here!", []string{"go", "23"}},
+		{"Don't panic: it's a trap", []string{"panic", "trap"}},
+		{"We'll see the shell", []string{"well", "see", "shell"}},
+		{"Café’s menu", []string{"cafés", "menu"}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.s); !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestNGrams(t *testing.T) {
+	tokens := []string{"rust", "go", "zig"}
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{0, nil},
+		{1, []string{"rust", "go", "zig"}},
+		{2, []string{"rust go", "go zig"}},
+		{3, []string{"rust go zig"}},
+		{4, nil},
+	}
+	for _, tt := range tests {
+		if got := NGrams(tokens, tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("NGrams(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		s    string
+		maxN int
+		want []string
+	}{
+		{"Go go GO", 2, []string{"go", "go go"}},
+		{"The state of the art", 3, []string{"state", "art"}},
+		{"state of the art compilers", 4, []string{"state", "art", "compilers", "art compilers", "state of the art"}},
+		{"a b c", 2, nil},
+	}
+	for _, tt := range tests {
+		if got := Terms(tt.s, tt.maxN); !slices.Equal(got, tt.want) {
+			t.Errorf("Terms(%q, %d) = %q, want %q", tt.s, tt.maxN, got, tt.want)
+		}
+	}
+}